@@ -6,19 +6,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"maps"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
 	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/encoding/sse"
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
 	"google.golang.org/genai"
 )
 
-// Options configures the converter behavior
+// Options configures the converter and ADK handler behavior
 type Options struct {
 	// IncludeRawEvents includes the original event in AG-UI events
 	IncludeRawEvents bool
@@ -26,6 +32,39 @@ type Options struct {
 	EmitStepEvents bool
 	// EmitActivityEvents emits ACTIVITY_DELTA for progress tracking
 	EmitActivityEvents bool
+	// EmitUsageEvents emits a CUSTOM "usage" event for each complete model
+	// response; result responses report usage either way
+	EmitUsageEvents bool
+	// CodeExecutionAsToolCall presents executable code and its result as a
+	// synthetic tool call instead of custom events
+	CodeExecutionAsToolCall bool
+	// Registry overrides the part and action converters; nil uses the built-in ones
+	Registry *ConverterRegistry
+	// Interceptors filter, rewrite or inject events before the ADK handler writes them
+	Interceptors []EventInterceptor
+	// ArtifactService is passed to the ADK runner so agents can save artifacts
+	ArtifactService artifact.Service
+	// RunTimeout cancels ADK runs that take longer; zero means no limit
+	RunTimeout time.Duration
+	// Runs records in-flight ADK runs so they can be cancelled by ID
+	Runs *RunRegistry
+	// Streams makes SSE runs resumable with Last-Event-ID
+	Streams *StreamStore
+	// BackgroundRuns answers a POST with 202 right away and runs the agent
+	// in the background
+	BackgroundRuns bool
+	// KeepAlive sends SSE heartbeats and a reconnect delay
+	KeepAlive SSEKeepAlive
+	// AgentFactory builds the agent per run instead of using the handler's agent
+	AgentFactory AgentFactory
+	// AgentKey returns the key runners built by AgentFactory are cached by
+	AgentKey AgentKeyFunc
+	// AgentCacheSize caps how many runners built by AgentFactory are cached;
+	// zero uses the default of 256
+	AgentCacheSize int
+	// UserID resolves the ADK user a run belongs to; nil runs every request
+	// as DefaultUserID
+	UserID UserIDFunc
 }
 
 // Option is a functional option for configuring the converter
@@ -41,11 +80,92 @@ func WithStepEvents(emit bool) Option {
 	return func(o *Options) { o.EmitStepEvents = emit }
 }
 
+// WithUsageEvents enables CUSTOM "usage" events with the token counts of
+// each model response
+func WithUsageEvents(emit bool) Option {
+	return func(o *Options) { o.EmitUsageEvents = emit }
+}
+
 // WithActivityEvents enables activity event emission
 func WithActivityEvents(emit bool) Option {
 	return func(o *Options) { o.EmitActivityEvents = emit }
 }
 
+// WithCodeExecutionAsToolCall maps code execution to TOOL_CALL_* events
+func WithCodeExecutionAsToolCall(enable bool) Option {
+	return func(o *Options) { o.CodeExecutionAsToolCall = enable }
+}
+
+// WithConverterRegistry sets a custom part/action converter registry
+func WithConverterRegistry(r *ConverterRegistry) Option {
+	return func(o *Options) { o.Registry = r }
+}
+
+// WithInterceptors adds event interceptors to the ADK handler
+func WithInterceptors(interceptors ...EventInterceptor) Option {
+	return func(o *Options) { o.Interceptors = append(o.Interceptors, interceptors...) }
+}
+
+// WithArtifactService sets the artifact service used by the ADK runner
+func WithArtifactService(svc artifact.Service) Option {
+	return func(o *Options) { o.ArtifactService = svc }
+}
+
+// WithRunTimeout cancels ADK runs that take longer than d
+func WithRunTimeout(d time.Duration) Option {
+	return func(o *Options) { o.RunTimeout = d }
+}
+
+// WithRunRegistry records ADK runs in reg so they can be cancelled by ID
+func WithRunRegistry(reg *RunRegistry) Option {
+	return func(o *Options) { o.Runs = reg }
+}
+
+// WithBackgroundRuns starts runs in the background: a POST returns 202 with
+// the run status and clients follow the run through the StreamStore endpoints
+func WithBackgroundRuns(enable bool) Option {
+	return func(o *Options) { o.BackgroundRuns = enable }
+}
+
+// WithStreamStore buffers SSE runs in store so clients can reconnect with
+// Last-Event-ID; the runs keep going while no client is connected
+func WithStreamStore(store *StreamStore) Option {
+	return func(o *Options) { o.Streams = store }
+}
+
+// WithSSEKeepAlive writes a heartbeat comment whenever an SSE stream has
+// been idle for heartbeat, and sends retry as the client reconnect delay.
+// Either may be zero.
+func WithSSEKeepAlive(heartbeat, retry time.Duration) Option {
+	return func(o *Options) { o.KeepAlive = SSEKeepAlive{Heartbeat: heartbeat, Retry: retry} }
+}
+
+// WithAgentFactory builds the agent of each run with factory, so its tools
+// and instructions can depend on the tenant or the run input. Runners are
+// cached by the key that key returns; a nil key builds the agent for every run.
+func WithAgentFactory(factory AgentFactory, key AgentKeyFunc) Option {
+	return func(o *Options) {
+		o.AgentFactory = factory
+		o.AgentKey = key
+	}
+}
+
+// WithAgentCacheSize keeps at most n runners built by WithAgentFactory,
+// dropping the least recently used one when a new key needs room
+func WithAgentCacheSize(n int) Option {
+	return func(o *Options) { o.AgentCacheSize = n }
+}
+
+// WithUserID resolves the ADK user of each run with fn, e.g.
+// UserIDHeader("X-User-ID") behind an authenticating proxy. Without it every
+// run uses DefaultUserID.
+func WithUserID(fn UserIDFunc) Option {
+	return func(o *Options) { o.UserID = fn }
+}
+
+// CodeExecutionToolName is the tool name used for synthetic code execution tool calls
+const CodeExecutionToolName = "code_execution"
+
 // ADKConverter converts ADK session.Event to AG-UI SDK events
 type ADKConverter struct {
 	mu sync.Mutex
@@ -54,8 +174,11 @@ type ADKConverter struct {
 	runID            string
 	currentMessageID string
 	messageStarted   bool
+	thinking         bool
 	activeToolCalls  map[string]bool
-	options          Options
+	// pendingCodeExecutions holds the tool call IDs of code awaiting a result, in order
+	pendingCodeExecutions []string
+	options               Options
 }
 
 // NewADKConverter creates a new ADK-specific converter
@@ -101,11 +224,9 @@ func (c *ADKConverter) FinishRun() []events.Event {
 
 	var result []events.Event
 
-	// Close any open message
-	if c.messageStarted {
-		result = append(result, events.NewTextMessageEndEvent(c.currentMessageID))
-		c.messageStarted = false
-	}
+	// Close any open thinking block and message
+	result = append(result, c.endThinking()...)
+	result = append(result, c.endTextMessage()...)
 
 	result = append(result, events.NewRunFinishedEvent(c.threadID, c.runID))
 	return result
@@ -118,11 +239,9 @@ func (c *ADKConverter) ErrorRun(err error) []events.Event {
 
 	var result []events.Event
 
-	// Close any open message
-	if c.messageStarted {
-		result = append(result, events.NewTextMessageEndEvent(c.currentMessageID))
-		c.messageStarted = false
-	}
+	// Close any open thinking block and message
+	result = append(result, c.endThinking()...)
+	result = append(result, c.endTextMessage()...)
 
 	result = append(result, events.NewRunErrorEvent(err.Error(), events.WithRunID(c.runID)))
 	return result
@@ -136,59 +255,56 @@ func (c *ADKConverter) ConvertEvent(adkEvent *session.Event) []events.Event {
 		result = append(result, events.NewRawEvent(adkEvent))
 	}
 
-	if adkEvent.Content != nil && len(adkEvent.Content.Parts) > 0 {
+	registry := c.options.Registry
+	if registry == nil {
+		registry = defaultRegistry
+	}
+
+	if adkEvent.Content != nil {
 		for _, part := range adkEvent.Content.Parts {
-			// Handle thinking/reasoning (Thought flag on text parts)
-			if part.Thought && part.Text != "" {
-				result = append(result, c.handleThought(adkEvent, part.Text)...)
+			if part == nil {
 				continue
 			}
-
-			// Handle text content
-			if part.Text != "" {
-				result = append(result, c.handleTextPart(adkEvent, part.Text)...)
-			}
-
-			// Handle function calls (tool invocations)
-			if part.FunctionCall != nil {
-				result = append(result, c.handleFunctionCall(part.FunctionCall)...)
-			}
-
-			// Handle function responses (tool results)
-			if part.FunctionResponse != nil {
-				result = append(result, c.handleFunctionResponse(part.FunctionResponse)...)
-			}
-
-			// Handle executable code (code generation)
-			if part.ExecutableCode != nil {
-				result = append(result, c.handleExecutableCode(part.ExecutableCode)...)
-			}
-
-			// Handle code execution results
-			if part.CodeExecutionResult != nil {
-				result = append(result, c.handleCodeExecutionResult(part.CodeExecutionResult)...)
-			}
-
-			// Handle inline data (images, files, etc.)
-			if part.InlineData != nil {
-				result = append(result, c.handleInlineData(part.InlineData)...)
-			}
-
-			// Handle file data references
-			if part.FileData != nil {
-				result = append(result, c.handleFileData(part.FileData)...)
+			converted := registry.convertPart(c, adkEvent, part)
+			// Anything but more of the thought ends a streamed thinking block
+			if !part.Thought && len(converted) > 0 {
+				result = append(result, c.EndThinking()...)
 			}
+			result = append(result, converted...)
 		}
 	}
 
-	// Handle state changes via actions
-	result = append(result, c.handleActions(&adkEvent.Actions)...)
+	// Handle state changes, transfers, etc. via actions
+	result = append(result, registry.convertActions(c, adkEvent, &adkEvent.Actions)...)
+
+	// Partial events repeat the usage of the response they belong to
+	if c.options.EmitUsageEvents && adkEvent.UsageMetadata != nil && !adkEvent.Partial {
+		result = append(result, c.handleUsage(adkEvent.UsageMetadata)...)
+	}
 
 	return result
 }
 
-// handleThought processes thinking/reasoning content from ADK events
+// handleUsage reports the tokens of one model response as a usage event
+func (c *ADKConverter) handleUsage(u *genai.GenerateContentResponseUsageMetadata) []events.Event {
+	return []events.Event{events.NewCustomEvent(
+		UsageEventName,
+		events.WithValue(Usage{
+			PromptTokens:     int(u.PromptTokenCount),
+			CompletionTokens: int(u.CandidatesTokenCount),
+			ThoughtsTokens:   int(u.ThoughtsTokenCount),
+			TotalTokens:      int(u.TotalTokenCount),
+		}),
+	)}
+}
+
+// handleThought processes thinking/reasoning content from ADK events. The
+// thoughts of partial events stream into one thinking block, which stays
+// open until other content, a complete event or the end of the run.
 func (c *ADKConverter) handleThought(adkEvent *session.Event, thought string) []events.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	var result []events.Event
 
 	// Skip empty thoughts
@@ -196,28 +312,45 @@ func (c *ADKConverter) handleThought(adkEvent *session.Event, thought string) []
 		return result
 	}
 
-	// Emit THINKING_START to begin the thinking phase
-	result = append(result, events.NewThinkingStartEvent())
+	if !c.thinking {
+		// Emit THINKING_START to begin the thinking phase
+		result = append(result, events.NewThinkingStartEvent())
 
-	if c.options.EmitActivityEvents {
-		result = append(result, events.NewActivitySnapshotEvent(c.currentMessageID, events.RoleActivity, map[string]string{
-			"type": "thinking",
-		}))
-	}
+		if c.options.EmitActivityEvents {
+			result = append(result, events.NewActivitySnapshotEvent(c.currentMessageID, events.RoleActivity, map[string]string{
+				"type": "thinking",
+			}))
+		}
 
-	// Emit THINKING_TEXT_MESSAGE_START
-	result = append(result, events.NewThinkingTextMessageStartEvent())
+		// Emit THINKING_TEXT_MESSAGE_START
+		result = append(result, events.NewThinkingTextMessageStartEvent())
+		c.thinking = true
+	}
 
 	// Emit THINKING_TEXT_MESSAGE_CONTENT with the actual thought content
 	result = append(result, events.NewThinkingTextMessageContentEvent(thought))
 
-	// Emit THINKING_TEXT_MESSAGE_END
-	result = append(result, events.NewThinkingTextMessageEndEvent())
+	if !adkEvent.Partial {
+		result = append(result, c.endThinking()...)
+	}
+	return result
+}
 
-	// Emit THINKING_END to close the thinking phase
-	result = append(result, events.NewThinkingEndEvent())
+// EndThinking closes the open thinking block, if any, returning its
+// THINKING_TEXT_MESSAGE_END and THINKING_END events
+func (c *ADKConverter) EndThinking() []events.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.endThinking()
+}
 
-	return result
+// endThinking is EndThinking without locking
+func (c *ADKConverter) endThinking() []events.Event {
+	if !c.thinking {
+		return nil
+	}
+	c.thinking = false
+	return []events.Event{events.NewThinkingTextMessageEndEvent(), events.NewThinkingEndEvent()}
 }
 
 // handleTextPart processes text content from ADK events
@@ -225,18 +358,13 @@ func (c *ADKConverter) handleTextPart(adkEvent *session.Event, text string) []ev
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	var result []events.Event
+	role := "assistant"
+	if adkEvent.Author == "user" {
+		role = "user"
+	}
 
 	// Start a new message if needed
-	if !c.messageStarted {
-		role := "assistant"
-		if adkEvent.Author == "user" {
-			role = "user"
-		}
-		c.currentMessageID = events.GenerateMessageID()
-		c.messageStarted = true
-		result = append(result, events.NewTextMessageStartEvent(c.currentMessageID, events.WithRole(role)))
-	}
+	result := c.startTextMessage(role)
 
 	// Add content chunk
 	result = append(result, events.NewTextMessageContentEvent(c.currentMessageID, text))
@@ -244,6 +372,47 @@ func (c *ADKConverter) handleTextPart(adkEvent *session.Event, text string) []ev
 	return result
 }
 
+// StartTextMessage opens a text message with the given role unless one is
+// already open. It returns the TEXT_MESSAGE_START event, if any.
+func (c *ADKConverter) StartTextMessage(role string) []events.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.startTextMessage(role)
+}
+
+// EndTextMessage closes the open text message, if any, returning its TEXT_MESSAGE_END event
+func (c *ADKConverter) EndTextMessage() []events.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.endTextMessage()
+}
+
+// CurrentMessageID returns the ID of the open or most recent text message
+func (c *ADKConverter) CurrentMessageID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.currentMessageID
+}
+
+// startTextMessage is StartTextMessage without locking
+func (c *ADKConverter) startTextMessage(role string) []events.Event {
+	if c.messageStarted {
+		return nil
+	}
+	c.currentMessageID = events.GenerateMessageID()
+	c.messageStarted = true
+	return []events.Event{events.NewTextMessageStartEvent(c.currentMessageID, events.WithRole(role))}
+}
+
+// endTextMessage is EndTextMessage without locking
+func (c *ADKConverter) endTextMessage() []events.Event {
+	if !c.messageStarted {
+		return nil
+	}
+	c.messageStarted = false
+	return []events.Event{events.NewTextMessageEndEvent(c.currentMessageID)}
+}
+
 // handleFunctionCall processes function call requests from ADK
 func (c *ADKConverter) handleFunctionCall(fc *genai.FunctionCall) []events.Event {
 	c.mu.Lock()
@@ -257,10 +426,7 @@ func (c *ADKConverter) handleFunctionCall(fc *genai.FunctionCall) []events.Event
 	}
 
 	// Close any open text message before tool call
-	if c.messageStarted {
-		result = append(result, events.NewTextMessageEndEvent(c.currentMessageID))
-		c.messageStarted = false
-	}
+	result = append(result, c.endTextMessage()...)
 
 	// Track this tool call
 	c.activeToolCalls[toolCallID] = true
@@ -317,61 +483,61 @@ func (c *ADKConverter) handleFunctionResponse(fr *genai.FunctionResponse) []even
 	return []events.Event{events.NewToolCallResultEvent(messageID, toolCallID, content)}
 }
 
-// handleActions processes ADK action signals (state changes, transfers, etc.)
-func (c *ADKConverter) handleActions(actions *session.EventActions) []events.Event {
-	var result []events.Event
-
-	// Handle state delta - convert map to JSON Patch operations
-	if len(actions.StateDelta) > 0 {
-		var ops []events.JSONPatchOperation
-		for key, value := range actions.StateDelta {
-			ops = append(ops, events.JSONPatchOperation{
-				Op:    "replace",
-				Path:  "/" + key,
-				Value: value,
-			})
-		}
-		result = append(result, events.NewStateDeltaEvent(ops))
-	}
-
-	// Handle artifact delta as a custom event
-	if len(actions.ArtifactDelta) > 0 {
-		result = append(result, events.NewCustomEvent(
-			"artifact_delta",
-			events.WithValue(actions.ArtifactDelta),
-		))
-	}
-
-	// Handle agent transfer as a custom event
-	if actions.TransferToAgent != "" {
-		result = append(result, events.NewCustomEvent(
-			"agent_transfer",
-			events.WithValue(map[string]string{
-				"targetAgent": actions.TransferToAgent,
-			}),
-		))
-
-		if c.options.EmitStepEvents {
-			// Finish current step (agent)
-			result = append(result, events.NewStepFinishedEvent(c.runID))
-			// Start new step (next agent)
-			result = append(result, events.NewStepStartedEvent(actions.TransferToAgent))
-		}
+// handleStateDelta converts an ADK state delta map to JSON Patch operations.
+// A delta may set keys the state doesn't have yet, so each key is an "add",
+// which replaces an existing value.
+func (c *ADKConverter) handleStateDelta(delta map[string]any) []events.Event {
+	ops := make([]events.JSONPatchOperation, 0, len(delta))
+	for _, key := range slices.Sorted(maps.Keys(delta)) {
+		ops = append(ops, events.JSONPatchOperation{
+			Op:    "add",
+			Path:  "/" + jsonPointerEscaper.Replace(key),
+			Value: delta[key],
+		})
 	}
+	return []events.Event{events.NewStateDeltaEvent(ops)}
+}
 
-	// Handle escalation as a custom event
-	if actions.Escalate {
-		result = append(result, events.NewCustomEvent(
-			"escalation",
-			events.WithValue(map[string]any{
-				"escalate": true,
-			}),
-		))
+// jsonPointerEscaper escapes a key for use as a JSON Pointer token
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// handleArtifactDelta emits the artifact delta as a custom event
+func (c *ADKConverter) handleArtifactDelta(delta map[string]int64) []events.Event {
+	return []events.Event{events.NewCustomEvent(
+		"artifact_delta",
+		events.WithValue(delta),
+	)}
+}
+
+// handleTransfer emits an agent transfer as a custom event, plus step events if enabled
+func (c *ADKConverter) handleTransfer(targetAgent string) []events.Event {
+	result := []events.Event{events.NewCustomEvent(
+		"agent_transfer",
+		events.WithValue(map[string]string{
+			"targetAgent": targetAgent,
+		}),
+	)}
+
+	if c.options.EmitStepEvents {
+		// Finish current step (agent)
+		result = append(result, events.NewStepFinishedEvent(c.runID))
+		// Start new step (next agent)
+		result = append(result, events.NewStepStartedEvent(targetAgent))
 	}
 
 	return result
 }
 
+// handleEscalation emits an escalation as a custom event
+func (c *ADKConverter) handleEscalation() []events.Event {
+	return []events.Event{events.NewCustomEvent(
+		"escalation",
+		events.WithValue(map[string]any{
+			"escalate": true,
+		}),
+	)}
+}
+
 // handleExecutableCode processes executable code parts from ADK
 func (c *ADKConverter) handleExecutableCode(code *genai.ExecutableCode) []events.Event {
 	var result []events.Event
@@ -380,6 +546,10 @@ func (c *ADKConverter) handleExecutableCode(code *genai.ExecutableCode) []events
 		return result
 	}
 
+	if c.options.CodeExecutionAsToolCall {
+		return c.startCodeExecutionToolCall(code)
+	}
+
 	// Emit as a custom event with code details
 	result = append(result, events.NewCustomEvent(
 		"executable_code",
@@ -400,6 +570,10 @@ func (c *ADKConverter) handleCodeExecutionResult(execResult *genai.CodeExecution
 		return result
 	}
 
+	if c.options.CodeExecutionAsToolCall {
+		return c.finishCodeExecutionToolCall(execResult)
+	}
+
 	// Emit as a custom event with execution result
 	result = append(result, events.NewCustomEvent(
 		"code_execution_result",
@@ -412,6 +586,73 @@ func (c *ADKConverter) handleCodeExecutionResult(execResult *genai.CodeExecution
 	return result
 }
 
+// startCodeExecutionToolCall emits executable code as a complete TOOL_CALL_START/ARGS/END
+// sequence and remembers its ID so the matching result can be linked to it
+func (c *ADKConverter) startCodeExecutionToolCall(code *genai.ExecutableCode) []events.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var result []events.Event
+
+	// Close any open text message before tool call
+	result = append(result, c.endTextMessage()...)
+
+	toolCallID := events.GenerateToolCallID()
+	c.pendingCodeExecutions = append(c.pendingCodeExecutions, toolCallID)
+	c.activeToolCalls[toolCallID] = true
+
+	result = append(result, events.NewToolCallStartEvent(toolCallID, CodeExecutionToolName))
+
+	argsJSON, err := json.Marshal(map[string]any{
+		"language": string(code.Language),
+		"code":     code.Code,
+	})
+	if err == nil {
+		result = append(result, events.NewToolCallArgsEvent(toolCallID, string(argsJSON)))
+	}
+
+	result = append(result, events.NewToolCallEndEvent(toolCallID))
+
+	return result
+}
+
+// finishCodeExecutionToolCall emits the code execution outcome as TOOL_CALL_RESULT,
+// linked to the oldest pending code execution tool call
+func (c *ADKConverter) finishCodeExecutionToolCall(execResult *genai.CodeExecutionResult) []events.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var result []events.Event
+
+	var toolCallID string
+	if len(c.pendingCodeExecutions) > 0 {
+		toolCallID = c.pendingCodeExecutions[0]
+		c.pendingCodeExecutions = c.pendingCodeExecutions[1:]
+	} else {
+		// Result without preceding code: open an empty call so the result has a parent
+		toolCallID = events.GenerateToolCallID()
+		result = append(result, c.endTextMessage()...)
+		result = append(result,
+			events.NewToolCallStartEvent(toolCallID, CodeExecutionToolName),
+			events.NewToolCallEndEvent(toolCallID),
+		)
+	}
+	delete(c.activeToolCalls, toolCallID)
+
+	content, err := json.Marshal(map[string]any{
+		"outcome": string(execResult.Outcome),
+		"output":  execResult.Output,
+	})
+	if err != nil {
+		content = []byte(execResult.Output)
+	}
+
+	messageID := events.GenerateMessageID()
+	result = append(result, events.NewToolCallResultEvent(messageID, toolCallID, string(content)))
+
+	return result
+}
+
 // handleInlineData processes inline binary data (images, files) from ADK
 func (c *ADKConverter) handleInlineData(blob *genai.Blob) []events.Event {
 	var result []events.Event
@@ -463,34 +704,107 @@ func (c *ADKConverter) IsMessageStarted() bool {
 // ADKHandler handles AG-UI protocol requests for ADK agents
 type ADKHandler struct {
 	runner         *runner.Runner
+	agents         *runnerCache
 	sessionService session.Service
 	appName        string
 	converterOpts  []Option
+	interceptors   interceptorChain
+	runs           *runScope
+	streams        *StreamStore
+	background     bool
+	keepAlive      SSEKeepAlive
+	userID         UserIDFunc
 }
 
-// NewADKHandler creates a new AG-UI handler for an ADK agent.
+// NewADKHandler creates a new AG-UI handler for an ADK agent. The agent may
+// be nil when WithAgentFactory builds it per run.
 func NewADKHandler(ag agent.Agent, sessionService session.Service, appName string, opts ...Option) (*ADKHandler, error) {
 	if appName == "" {
 		appName = "adk-agent"
 	}
 
-	r, err := runner.New(runner.Config{
-		AppName:        appName,
-		Agent:          ag,
-		SessionService: sessionService,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create runner: %w", err)
+	options := Options{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	newRunner := func(ag agent.Agent) (*runner.Runner, error) {
+		return runner.New(runner.Config{
+			AppName:         appName,
+			Agent:           ag,
+			SessionService:  sessionService,
+			ArtifactService: options.ArtifactService,
+		})
+	}
+
+	var r *runner.Runner
+	var agents *runnerCache
+	if options.AgentFactory != nil {
+		agents = newRunnerCache(newRunner, options.AgentFactory, options.AgentKey, options.AgentCacheSize)
+	} else {
+		var err error
+		if r, err = newRunner(ag); err != nil {
+			return nil, fmt.Errorf("failed to create runner: %w", err)
+		}
+	}
+
+	userID := options.UserID
+	if userID == nil {
+		userID = defaultUserID
+	}
+
+	streams := options.Streams
+	if options.BackgroundRuns && streams == nil {
+		streams = NewStreamStore(0).WithKeepAlive(options.KeepAlive)
 	}
 
 	return &ADKHandler{
 		runner:         r,
+		agents:         agents,
 		sessionService: sessionService,
 		appName:        appName,
 		converterOpts:  opts,
+		interceptors:   interceptorChain(options.Interceptors),
+		runs:           newRunScope(options.RunTimeout, options.Runs),
+		streams:        streams,
+		background:     options.BackgroundRuns,
+		keepAlive:      options.KeepAlive,
+		userID:         userID,
 	}, nil
 }
 
+// Streams returns the store buffering the handler's runs, or nil
+func (h *ADKHandler) Streams() *StreamStore {
+	return h.streams
+}
+
+// WebSocket returns a handler that serves the same ADK runs over a WebSocket
+func (h *ADKHandler) WebSocket() *WebSocketHandler {
+	return newWebSocketHandler(h.runs, h.userID, h.streamEvents)
+}
+
+// EvictAgent drops the runner cached under key, so the next run with that
+// key builds its agent again. It does nothing without WithAgentFactory.
+func (h *ADKHandler) EvictAgent(key string) {
+	if h.agents != nil {
+		h.agents.evict(key)
+	}
+}
+
+// runnerFor returns the runner of a run
+func (h *ADKHandler) runnerFor(hctx HandlerContext, input RunAgentInput) (*runner.Runner, error) {
+	if h.agents == nil {
+		return h.runner, nil
+	}
+	return h.agents.get(hctx, input)
+}
+
+// Shutdown cancels all in-flight ADK runs. Register it with
+// http.Server.RegisterOnShutdown so streaming runs end with the server.
+func (h *ADKHandler) Shutdown() {
+	h.runs.shutdown()
+}
+
 // ensureSession creates a session if it doesn't exist
 func (h *ADKHandler) ensureSession(ctx context.Context, userID, sessionID string) error {
 	_, err := h.sessionService.Get(ctx, &session.GetRequest{
@@ -543,142 +857,351 @@ func (h *ADKHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		input.RunID = events.GenerateRunID()
 	}
 
+	hctx := HandlerContext{
+		ThreadID: input.ThreadID,
+		RunID:    input.RunID,
+		UserID:   h.userID(r),
+		Request:  r,
+	}
+
+	// Background runs are detached from the connection
+	if h.background {
+		serveBackgroundRun(w, r, h.streams, h.runs, hctx, input, func(hctx HandlerContext, emit func([]events.Event) bool) {
+			h.streamEvents(hctx, input, emit)
+		})
+		return
+	}
+
 	// Determine encoding based on Accept header
-	accept := r.Header.Get("Accept")
-	if accept == "" || accept == "text/event-stream" || accept == "*/*" {
-		h.handleSSE(w, r.Context(), input)
-	} else {
-		h.handleJSON(w, r.Context(), input)
+	contentType, ok := negotiateResponse(w, r)
+	if !ok {
+		return
+	}
+
+	// So are resumable SSE runs
+	if contentType == ContentTypeSSE && h.streams != nil {
+		serveResumableSSE(w, r, h.streams, h.runs, hctx, input, h.keepAlive, func(hctx HandlerContext, emit func([]events.Event) bool) {
+			h.streamEvents(hctx, input, emit)
+		})
+		return
+	}
+
+	// The run stops when the client disconnects, the handler shuts down or
+	// the run times out or is cancelled through the run registry
+	ctx, cancel := h.runs.runContext(r.Context(), hctx)
+	defer cancel()
+	hctx.Context = ctx
+
+	switch contentType {
+	case ContentTypeSSE:
+		h.handleSSE(w, ctx, hctx, input)
+	case ContentTypeProto:
+		h.handleProto(w, hctx, input)
+	case ContentTypeNDJSON:
+		h.handleNDJSON(w, hctx, input)
+	case ContentTypeResult:
+		h.handleResult(w, hctx, input)
+	default:
+		h.handleJSON(w, hctx, input)
+	}
+}
+
+// DefaultUserID is the user runs and artifacts belong to when no UserIDFunc says otherwise
+const DefaultUserID = "default-user"
+
+// UserIDFunc resolves the user a request acts as. It should derive the user
+// from something the server has authenticated, such as a session cookie or a
+// verified token.
+type UserIDFunc func(r *http.Request) string
+
+// UserIDHeader returns a UserIDFunc reading the named header, falling back to
+// DefaultUserID. The header is set by the client and is not authenticated:
+// only use it behind a proxy or middleware that sets or verifies it.
+func UserIDHeader(name string) UserIDFunc {
+	return func(r *http.Request) string {
+		if userID := r.Header.Get(name); userID != "" {
+			return userID
+		}
+		return DefaultUserID
 	}
 }
 
+// defaultUserID runs every request as DefaultUserID
+func defaultUserID(*http.Request) string {
+	return DefaultUserID
+}
+
 func (h *ADKHandler) handleCORS(w http.ResponseWriter) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization, X-User-ID, Last-Event-ID")
 	w.WriteHeader(http.StatusOK)
 }
 
 // handleSSE handles Server-Sent Events streaming
-func (h *ADKHandler) handleSSE(w http.ResponseWriter, ctx context.Context, input RunAgentInput) {
-	// Set SSE headers
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("X-Accel-Buffering", "no")
+func (h *ADKHandler) handleSSE(w http.ResponseWriter, ctx context.Context, hctx HandlerContext, input RunAgentInput) {
+	conn := startSSE(w, h.keepAlive)
+	defer conn.close()
 
-	conv := NewADKConverter(input.ThreadID, input.RunID, h.converterOpts...)
 	writer := sse.NewSSEWriter()
 
-	// Send RUN_STARTED
-	if err := writer.WriteEvent(ctx, w, conv.StartRun()); err != nil {
-		return
-	}
-	if f, ok := w.(http.Flusher); ok {
-		f.Flush()
-	}
-
-	// Convert AG-UI messages to ADK content
-	adkContent := convertMessagesToADKContent(input.Messages)
-
-	userID := "default-user"
-	sessionID := input.ThreadID
+	h.streamEvents(hctx, input, func(evts []events.Event) bool {
+		for _, evt := range evts {
+			if err := conn.write(func(w io.Writer) error { return writer.WriteEvent(ctx, w, evt) }); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+}
 
-	if err := h.ensureSession(ctx, userID, sessionID); err != nil {
-		writer.WriteErrorEvent(ctx, w, err, input.RunID)
-		return
-	}
+// handleProto streams length-prefixed protobuf events, flushing after each event
+func (h *ADKHandler) handleProto(w http.ResponseWriter, hctx HandlerContext, input RunAgentInput) {
+	w.Header().Set("Content-Type", ContentTypeProto)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	errorOccurred := false
+	h.streamEvents(hctx, input, func(evts []events.Event) bool {
+		for _, evt := range evts {
+			if clientGone(hctx) {
+				return false
+			}
+			data, err := encodeProtoEvent(evt)
+			if err != nil {
+				log.Printf("[AG-UI] Failed to encode event: %v", err)
+				continue
+			}
+			if err := writeProtoFrame(w, data); err != nil {
+				return false
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+		return true
+	})
+}
 
-	for adkEvent, err := range h.runner.Run(ctx, userID, sessionID, adkContent, agent.RunConfig{}) {
-		if err != nil {
-			writer.WriteErrorEvent(ctx, w, err, input.RunID)
-			errorOccurred = true
-			break
+// handleResult runs the agent to the end and answers with its outcome
+// instead of the events
+func (h *ADKHandler) handleResult(w http.ResponseWriter, hctx HandlerContext, input RunAgentInput) {
+	// The result always reports usage, whether or not the stream would
+	collector := newRunResultCollector(input.State)
+	opts := append(slices.Clip(h.converterOpts), WithUsageEvents(true))
+	h.runAgent(hctx, input, opts, func(evts []events.Event) bool {
+		for _, evt := range evts {
+			collector.apply(evt)
 		}
+		return !clientGone(hctx)
+	})
+	writeRunResult(w, collector.result(hctx))
+}
 
-		aguiEvents := conv.ConvertEvent(adkEvent)
-		for _, evt := range aguiEvents {
-			if err := writer.WriteEvent(ctx, w, evt); err != nil {
-				return
+// handleNDJSON streams events as newline-delimited JSON, flushing after each event
+func (h *ADKHandler) handleNDJSON(w http.ResponseWriter, hctx HandlerContext, input RunAgentInput) {
+	w.Header().Set("Content-Type", ContentTypeNDJSON)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	h.streamEvents(hctx, input, func(evts []events.Event) bool {
+		for _, evt := range evts {
+			if clientGone(hctx) {
+				return false
+			}
+			if err := writeNDJSONEvent(w, evt); err != nil {
+				return false
 			}
 			if f, ok := w.(http.Flusher); ok {
 				f.Flush()
 			}
 		}
-	}
+		return true
+	})
+}
+
+// handleJSON streams the events as a JSON array, flushing after each event
+func (h *ADKHandler) handleJSON(w http.ResponseWriter, hctx HandlerContext, input RunAgentInput) {
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	if !errorOccurred {
-		for _, evt := range conv.FinishRun() {
-			if err := writer.WriteEvent(ctx, w, evt); err != nil {
-				return
+	array := newJSONArrayWriter(w)
+	h.streamEvents(hctx, input, func(evts []events.Event) bool {
+		for _, evt := range evts {
+			if clientGone(hctx) {
+				return false
+			}
+			if err := array.write(evt); err != nil {
+				return false
 			}
 			if f, ok := w.(http.Flusher); ok {
 				f.Flush()
 			}
 		}
-	}
+		return true
+	})
+	array.close()
+}
+
+// streamEvents runs the agent, converts its events, passes them through the
+// interceptors and hands the result to emit. It stops early when emit
+// returns false.
+func (h *ADKHandler) streamEvents(hctx HandlerContext, input RunAgentInput, emit func([]events.Event) bool) {
+	h.runAgent(hctx, input, h.converterOpts, emit)
 }
 
-// handleJSON handles non-streaming JSON responses
-func (h *ADKHandler) handleJSON(w http.ResponseWriter, ctx context.Context, input RunAgentInput) {
-	conv := NewADKConverter(input.ThreadID, input.RunID, h.converterOpts...)
-	var allEvents []events.Event
+// runAgent is streamEvents with the converter options of the run
+func (h *ADKHandler) runAgent(hctx HandlerContext, input RunAgentInput, converterOpts []Option, emit func([]events.Event) bool) {
+	ctx := hctx.Context
+	conv := NewADKConverter(input.ThreadID, input.RunID, converterOpts...)
+
+	// A run that ends flushes its interceptors before the terminal event;
+	// when the client is gone they are still flushed to release their state
+	ended := false
+	defer func() {
+		if !ended {
+			h.interceptors.Flush(hctx)
+		}
+	}()
+
+	write := func(evts ...events.Event) bool {
+		for _, evt := range evts {
+			if !emit(h.interceptors.Intercept(hctx, evt)) {
+				return false
+			}
+		}
+		return true
+	}
+	end := func(evts ...events.Event) {
+		ended = true
+		emit(h.interceptors.finish(hctx, evts))
+	}
 
-	allEvents = append(allEvents, conv.StartRun())
+	// Send RUN_STARTED
+	if !write(conv.StartRun()) {
+		return
+	}
 
+	// Convert AG-UI messages to ADK content
 	adkContent := convertMessagesToADKContent(input.Messages)
 
-	userID := "default-user"
+	userID := hctx.UserID
 	sessionID := input.ThreadID
 
 	if err := h.ensureSession(ctx, userID, sessionID); err != nil {
-		allEvents = append(allEvents, events.NewRunErrorEvent(err.Error(), events.WithRunID(input.RunID)))
-		h.writeJSONEvents(w, allEvents)
+		end(conv.ErrorRun(err)...)
+		return
+	}
+
+	r, err := h.runnerFor(hctx, input)
+	if err != nil {
+		end(conv.ErrorRun(err)...)
 		return
 	}
 
-	errorOccurred := false
+	var runErr error
+	partial := &partialMessage{}
 
-	for adkEvent, err := range h.runner.Run(ctx, userID, sessionID, adkContent, agent.RunConfig{}) {
+	for adkEvent, err := range r.Run(ctx, userID, sessionID, adkContent, agent.RunConfig{}) {
+		if runCancelled(ctx) != nil {
+			break
+		}
 		if err != nil {
-			allEvents = append(allEvents, events.NewRunErrorEvent(err.Error(), events.WithRunID(input.RunID)))
-			errorOccurred = true
+			runErr = err
 			break
 		}
 
-		allEvents = append(allEvents, conv.ConvertEvent(adkEvent)...)
+		partial.observe(adkEvent)
+		if !write(conv.ConvertEvent(adkEvent)...) {
+			return
+		}
+	}
+
+	if cause := runCancelled(ctx); cause != nil {
+		end(h.cancelRun(ctx, hctx, conv, partial, cause)...)
+	} else if runErr != nil {
+		end(conv.ErrorRun(runErr)...)
+	} else {
+		end(conv.FinishRun()...)
+	}
+}
+
+// cancelRun records the partial answer of a cancelled run in the session
+// and returns the events that end the stream
+func (h *ADKHandler) cancelRun(ctx context.Context, hctx HandlerContext, conv *ADKConverter, partial *partialMessage, cause error) []events.Event {
+	// The run context is already cancelled; the session write must still happen
+	if err := h.savePartial(context.WithoutCancel(ctx), hctx, partial); err != nil {
+		log.Printf("[AG-UI] Failed to save partial message: %v", err)
 	}
+	return conv.ErrorRun(cause)
+}
 
-	if !errorOccurred {
-		allEvents = append(allEvents, conv.FinishRun()...)
+// savePartial appends the streamed but unpersisted text as an interrupted event
+func (h *ADKHandler) savePartial(ctx context.Context, hctx HandlerContext, partial *partialMessage) error {
+	if partial.text.Len() == 0 {
+		return nil
 	}
 
-	h.writeJSONEvents(w, allEvents)
+	resp, err := h.sessionService.Get(ctx, &session.GetRequest{
+		AppName:   h.appName,
+		UserID:    hctx.UserID,
+		SessionID: hctx.ThreadID,
+	})
+	if err != nil {
+		return err
+	}
+
+	evt := session.NewEvent(partial.invocationID)
+	evt.Author = partial.author
+	evt.Branch = partial.branch
+	evt.Content = genai.NewContentFromText(partial.text.String(), genai.RoleModel)
+	evt.Interrupted = true
+
+	return h.sessionService.AppendEvent(ctx, resp.Session, evt)
 }
 
-func (h *ADKHandler) writeJSONEvents(w http.ResponseWriter, evts []events.Event) {
-	var jsonEvents []json.RawMessage
-	for _, evt := range evts {
-		data, err := evt.ToJSON()
-		if err != nil {
-			continue
-		}
-		jsonEvents = append(jsonEvents, data)
+// partialMessage accumulates streamed text that ADK has not persisted yet.
+// ADK only stores the final aggregated event, so text from partial events is
+// lost if the run is cancelled before it arrives.
+type partialMessage struct {
+	author       string
+	branch       string
+	invocationID string
+	text         strings.Builder
+}
+
+func (p *partialMessage) observe(adkEvent *session.Event) {
+	if !adkEvent.Partial {
+		p.text.Reset()
+		return
+	}
+	if adkEvent.Content == nil {
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(jsonEvents)
+	p.author = adkEvent.Author
+	p.branch = adkEvent.Branch
+	p.invocationID = adkEvent.InvocationID
+	for _, part := range adkEvent.Content.Parts {
+		if part != nil && !part.Thought {
+			p.text.WriteString(part.Text)
+		}
+	}
 }
 
-// convertMessagesToADKContent converts AG-UI messages to ADK content format
+// convertMessagesToADKContent converts the new input of a run to ADK
+// content. The session already holds the earlier turns, so this is the text
+// of the last user message or, when the input ends with tool messages, their
+// results as function responses.
 func convertMessagesToADKContent(messages []Message) *genai.Content {
 	if len(messages) == 0 {
 		return nil
 	}
 
+	if messages[len(messages)-1].Role == "tool" {
+		return convertToolMessagesToADKContent(messages)
+	}
+
 	var lastUserMessage *Message
 	for i := len(messages) - 1; i >= 0; i-- {
 		if messages[i].Role == "user" {
@@ -707,3 +1230,38 @@ func convertMessagesToADKContent(messages []Message) *genai.Content {
 		Parts: parts,
 	}
 }
+
+// convertToolMessagesToADKContent sends the tool messages at the end of the
+// input as one content of function responses, named after the assistant tool
+// calls they answer
+func convertToolMessagesToADKContent(messages []Message) *genai.Content {
+	first := len(messages)
+	for first > 0 && messages[first-1].Role == "tool" {
+		first--
+	}
+
+	toolNames := make(map[string]string)
+	for _, msg := range messages[:first] {
+		for _, call := range msg.ToolCalls {
+			toolNames[call.ID] = call.Function.Name
+		}
+	}
+
+	parts := make([]*genai.Part, 0, len(messages)-first)
+	for _, msg := range messages[first:] {
+		name := toolNames[msg.ToolCallID]
+		if name == "" {
+			name = msg.Name
+		}
+		parts = append(parts, &genai.Part{FunctionResponse: &genai.FunctionResponse{
+			ID:       msg.ToolCallID,
+			Name:     name,
+			Response: toolResponse(msg.Content),
+		}})
+	}
+
+	return &genai.Content{
+		Role:  genai.RoleUser,
+		Parts: parts,
+	}
+}
@@ -1,11 +1,17 @@
 package aguigo
 
 import (
+	"context"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/agent"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
 	"google.golang.org/genai"
@@ -288,6 +294,27 @@ func TestADKConverter_HandleStateDelta(t *testing.T) {
 
 		require.Len(t, evts, 1)
 		assert.Equal(t, events.EventTypeStateDelta, evts[0].Type())
+		assert.Equal(t, []events.JSONPatchOperation{
+			{Op: "add", Path: "/counter", Value: 42},
+			{Op: "add", Path: "/status", Value: "active"},
+		}, evts[0].(*events.StateDeltaEvent).Delta)
+	})
+
+	t.Run("deltas apply to a run without state", func(t *testing.T) {
+		conv := NewADKConverter("thread-1", "run-1")
+		r := NewReducer()
+		require.NoError(t, r.Apply(events.NewRunStartedEvent("thread-1", "run-1")))
+
+		for _, delta := range []map[string]any{
+			{"counter": 1, "a/b": "slash"},
+			{"counter": 2},
+		} {
+			for _, evt := range conv.ConvertEvent(&session.Event{Actions: session.EventActions{StateDelta: delta}}) {
+				require.NoError(t, r.Apply(evt))
+			}
+		}
+
+		assert.Equal(t, map[string]any{"counter": float64(2), "a/b": "slash"}, r.State())
 	})
 }
 
@@ -381,6 +408,89 @@ func TestADKConverter_HandleCodeExecutionResult(t *testing.T) {
 	})
 }
 
+func TestADKConverter_CodeExecutionAsToolCall(t *testing.T) {
+	t.Run("emits linked tool call and result", func(t *testing.T) {
+		conv := NewADKConverter("thread-1", "run-1", WithCodeExecutionAsToolCall(true))
+
+		codeEvt := &session.Event{
+			Author: "assistant",
+			LLMResponse: model.LLMResponse{
+				Content: &genai.Content{
+					Parts: []*genai.Part{
+						{
+							ExecutableCode: &genai.ExecutableCode{
+								Language: genai.LanguagePython,
+								Code:     "print(1 + 1)",
+							},
+						},
+					},
+				},
+			},
+		}
+		evts := conv.ConvertEvent(codeEvt)
+
+		// Should emit: TOOL_CALL_START, TOOL_CALL_ARGS, TOOL_CALL_END
+		require.Len(t, evts, 3)
+		start, ok := evts[0].(*events.ToolCallStartEvent)
+		require.True(t, ok)
+		assert.Equal(t, CodeExecutionToolName, start.ToolCallName)
+		args, ok := evts[1].(*events.ToolCallArgsEvent)
+		require.True(t, ok)
+		assert.JSONEq(t, `{"language":"PYTHON","code":"print(1 + 1)"}`, args.Delta)
+		assert.Equal(t, events.EventTypeToolCallEnd, evts[2].Type())
+
+		resultEvt := &session.Event{
+			Author: "assistant",
+			LLMResponse: model.LLMResponse{
+				Content: &genai.Content{
+					Parts: []*genai.Part{
+						{
+							CodeExecutionResult: &genai.CodeExecutionResult{
+								Outcome: genai.OutcomeOK,
+								Output:  "2",
+							},
+						},
+					},
+				},
+			},
+		}
+		evts = conv.ConvertEvent(resultEvt)
+
+		require.Len(t, evts, 1)
+		res, ok := evts[0].(*events.ToolCallResultEvent)
+		require.True(t, ok)
+		assert.Equal(t, start.ToolCallID, res.ToolCallID)
+		assert.JSONEq(t, `{"outcome":"OUTCOME_OK","output":"2"}`, res.Content)
+	})
+
+	t.Run("opens a tool call for an orphan result", func(t *testing.T) {
+		conv := NewADKConverter("thread-1", "run-1", WithCodeExecutionAsToolCall(true))
+
+		adkEvent := &session.Event{
+			Author: "assistant",
+			LLMResponse: model.LLMResponse{
+				Content: &genai.Content{
+					Parts: []*genai.Part{
+						{
+							CodeExecutionResult: &genai.CodeExecutionResult{
+								Outcome: genai.OutcomeFailed,
+								Output:  "boom",
+							},
+						},
+					},
+				},
+			},
+		}
+		evts := conv.ConvertEvent(adkEvent)
+
+		// Should emit: TOOL_CALL_START, TOOL_CALL_END, TOOL_CALL_RESULT
+		require.Len(t, evts, 3)
+		assert.Equal(t, events.EventTypeToolCallStart, evts[0].Type())
+		assert.Equal(t, events.EventTypeToolCallEnd, evts[1].Type())
+		assert.Equal(t, events.EventTypeToolCallResult, evts[2].Type())
+	})
+}
+
 func TestADKConverter_FinishRunClosesOpenMessage(t *testing.T) {
 	conv := NewADKConverter("thread-1", "run-1")
 
@@ -407,3 +517,130 @@ func TestADKConverter_FinishRunClosesOpenMessage(t *testing.T) {
 	assert.Equal(t, events.EventTypeRunFinished, evts[1].Type())
 	assert.False(t, conv.IsMessageStarted())
 }
+
+func TestADKHandler_ServeHTTP(t *testing.T) {
+	handler := newTestADKHandler(t, []model.LLMResponse{
+		{Content: genai.NewContentFromText("Hello", genai.RoleModel)},
+		{Content: genai.NewContentFromText(" there", genai.RoleModel)},
+	})
+
+	t.Run("SSE streaming", func(t *testing.T) {
+		body := `{"threadId":"thread-1","runId":"run-1","messages":[{"id":"m1","role":"user","content":"Hi"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Accept", "text/event-stream")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+		out := rr.Body.String()
+		assert.Contains(t, out, `"type":"RUN_STARTED"`)
+		assert.Contains(t, out, `"delta":"Hello"`)
+		assert.Contains(t, out, `"type":"TEXT_MESSAGE_END"`)
+		assert.Contains(t, out, `"type":"RUN_FINISHED"`)
+	})
+
+	t.Run("JSON response", func(t *testing.T) {
+		body := `{"threadId":"thread-2","messages":[{"id":"m1","role":"user","content":"Hi"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		evts := decodeJSONEvents(t, rr.Body.Bytes())
+		require.NotEmpty(t, evts)
+		assert.Equal(t, "RUN_STARTED", evts[0]["type"])
+		assert.Equal(t, "RUN_FINISHED", evts[len(evts)-1]["type"])
+	})
+}
+
+func TestADKHandler_UserID(t *testing.T) {
+	run := func(t *testing.T, handler *ADKHandler) {
+		body := `{"threadId":"thread-1","messages":[{"id":"m1","role":"user","content":"Hi"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-User-ID", "alice")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+	sessionUser := func(handler *ADKHandler, userID string) error {
+		_, err := handler.sessionService.Get(context.Background(), &session.GetRequest{AppName: "test-app", UserID: userID, SessionID: "thread-1"})
+		return err
+	}
+	responses := []model.LLMResponse{{Content: genai.NewContentFromText("Hello", genai.RoleModel)}}
+
+	t.Run("ignores X-User-ID by default", func(t *testing.T) {
+		handler := newTestADKHandler(t, responses)
+		run(t, handler)
+
+		assert.NoError(t, sessionUser(handler, DefaultUserID))
+		assert.Error(t, sessionUser(handler, "alice"))
+	})
+
+	t.Run("resolves the user with WithUserID", func(t *testing.T) {
+		handler := newTestADKHandler(t, responses, WithUserID(UserIDHeader("X-User-ID")))
+		run(t, handler)
+
+		assert.NoError(t, sessionUser(handler, "alice"))
+		assert.Error(t, sessionUser(handler, DefaultUserID))
+	})
+}
+
+func TestADKHandler_ToolResults(t *testing.T) {
+	var received *genai.Content
+	ag, err := agent.New(agent.Config{
+		Name: "test_agent",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			received = ctx.UserContent()
+			return func(yield func(*session.Event, error) bool) {
+				evt := session.NewEvent(ctx.InvocationID())
+				evt.Author = "test_agent"
+				evt.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText("It is sunny", genai.RoleModel)}
+				yield(evt, nil)
+			}
+		},
+	})
+	require.NoError(t, err)
+	sessions := session.InMemoryService()
+	handler, err := NewADKHandler(ag, sessions, "test-app")
+	require.NoError(t, err)
+
+	// The follow-up run of a frontend tool call carries its result
+	body := `{"threadId":"thread-1","runId":"run-2","messages":[
+		{"id":"m1","role":"user","content":"Weather in Paris?"},
+		{"id":"m2","role":"assistant","toolCalls":[{"id":"call-1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},
+		{"id":"m3","role":"tool","toolCallId":"call-1","content":"{\"sky\":\"sunny\"}"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Accept", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"delta":"It is sunny"`)
+
+	require.NotNil(t, received)
+	require.Len(t, received.Parts, 1)
+	assert.Equal(t, &genai.FunctionResponse{
+		ID:       "call-1",
+		Name:     "get_weather",
+		Response: map[string]any{"sky": "sunny"},
+	}, received.Parts[0].FunctionResponse)
+
+	// The result is recorded in the session like a tool the agent ran itself
+	resp, err := sessions.Get(context.Background(), &session.GetRequest{AppName: "test-app", UserID: DefaultUserID, SessionID: "thread-1"})
+	require.NoError(t, err)
+	var responses []string
+	for evt := range resp.Session.Events().All() {
+		if evt.Content == nil {
+			continue
+		}
+		for _, part := range evt.Content.Parts {
+			if part.FunctionResponse != nil {
+				responses = append(responses, part.FunctionResponse.Name)
+			}
+		}
+	}
+	assert.Equal(t, []string{"get_weather"}, responses)
+}
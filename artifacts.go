@@ -0,0 +1,356 @@
+package aguigo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+)
+
+// defaultMaxUploadSize caps multipart uploads accepted by ArtifactHandler
+const defaultMaxUploadSize = 32 << 20
+
+// ArtifactInfo describes a stored artifact and its available versions
+type ArtifactInfo struct {
+	Name     string  `json:"name"`
+	Versions []int64 `json:"versions,omitempty"`
+}
+
+// ArtifactHandler exposes an ADK artifact service over HTTP so that the
+// artifacts referenced by artifact_delta events can be listed, downloaded
+// and uploaded by the frontend. Artifacts are scoped by app, user and thread;
+// the user comes from WithUserID and is DefaultUserID without it, so any
+// caller that can reach the handler sees every thread's artifacts.
+//
+// Routes are relative to the mount point:
+//
+//	GET  /{threadId}                     list artifacts in the thread
+//	POST /{threadId}                     upload one or more files (multipart "file" fields)
+//	GET  /{threadId}/{name}              download the latest version (?version=N, N >= 1, for a specific one)
+//	GET  /{threadId}/{name}/versions     list the versions of an artifact
+//
+// Mount it with http.StripPrefix, e.g.
+//
+//	http.Handle("/api/artifacts/", http.StripPrefix("/api/artifacts", h))
+type ArtifactHandler struct {
+	service       artifact.Service
+	appName       string
+	maxUploadSize int64
+	userID        UserIDFunc
+	mux           *http.ServeMux
+}
+
+// NewArtifactHandler creates an HTTP handler for the given artifact service.
+// appName must match the app name used by the ADKHandler producing the artifacts.
+func NewArtifactHandler(service artifact.Service, appName string) *ArtifactHandler {
+	if appName == "" {
+		appName = "adk-agent"
+	}
+
+	h := &ArtifactHandler{
+		service:       service,
+		appName:       appName,
+		maxUploadSize: defaultMaxUploadSize,
+		userID:        defaultUserID,
+		mux:           http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /{threadID}", h.handleList)
+	h.mux.HandleFunc("POST /{threadID}", h.handleUpload)
+	h.mux.HandleFunc("GET /{threadID}/{name}", h.handleDownload)
+	h.mux.HandleFunc("GET /{threadID}/{name}/versions", h.handleVersions)
+
+	return h
+}
+
+// WithMaxUploadSize sets the maximum accepted upload size in bytes
+func (h *ArtifactHandler) WithMaxUploadSize(n int64) *ArtifactHandler {
+	h.maxUploadSize = n
+	return h
+}
+
+// WithUserID scopes artifacts to the user fn resolves. It should match the
+// ADKHandler's WithUserID.
+func (h *ArtifactHandler) WithUserID(fn UserIDFunc) *ArtifactHandler {
+	h.userID = fn
+	return h
+}
+
+// ServeHTTP handles artifact requests
+func (h *ArtifactHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization, X-User-ID")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+// handleList returns the artifacts stored for a thread along with their versions
+func (h *ArtifactHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := h.userID(r)
+	threadID := r.PathValue("threadID")
+
+	req := &artifact.ListRequest{
+		AppName:   h.appName,
+		UserID:    userID,
+		SessionID: threadID,
+	}
+	if err := validateArtifactRequest(req); err != nil {
+		writeArtifactError(w, err)
+		return
+	}
+	resp, err := h.service.List(ctx, req)
+	if err != nil {
+		writeArtifactError(w, err)
+		return
+	}
+
+	artifacts := make([]ArtifactInfo, 0, len(resp.FileNames))
+	for _, name := range resp.FileNames {
+		info := ArtifactInfo{Name: name}
+		versions, err := h.service.Versions(ctx, &artifact.VersionsRequest{
+			AppName:   h.appName,
+			UserID:    userID,
+			SessionID: threadID,
+			FileName:  name,
+		})
+		if err == nil {
+			info.Versions = versions.Versions
+		}
+		artifacts = append(artifacts, info)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"threadId":  threadID,
+		"artifacts": artifacts,
+	})
+}
+
+// handleVersions returns the versions available for a single artifact
+func (h *ArtifactHandler) handleVersions(w http.ResponseWriter, r *http.Request) {
+	req := &artifact.VersionsRequest{
+		AppName:   h.appName,
+		UserID:    h.userID(r),
+		SessionID: r.PathValue("threadID"),
+		FileName:  r.PathValue("name"),
+	}
+	if err := validateArtifactRequest(req); err != nil {
+		writeArtifactError(w, err)
+		return
+	}
+	resp, err := h.service.Versions(r.Context(), req)
+	if err != nil {
+		writeArtifactError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ArtifactInfo{
+		Name:     r.PathValue("name"),
+		Versions: resp.Versions,
+	})
+}
+
+// handleDownload writes the artifact content with its MIME type
+func (h *ArtifactHandler) handleDownload(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var version int64
+	if v := r.URL.Query().Get("version"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid version: must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		version = parsed
+	}
+
+	req := &artifact.LoadRequest{
+		AppName:   h.appName,
+		UserID:    h.userID(r),
+		SessionID: r.PathValue("threadID"),
+		FileName:  name,
+		Version:   version,
+	}
+	if err := validateArtifactRequest(req); err != nil {
+		writeArtifactError(w, err)
+		return
+	}
+	resp, err := h.service.Load(r.Context(), req)
+	if err != nil {
+		writeArtifactError(w, err)
+		return
+	}
+
+	var (
+		data     []byte
+		mimeType string
+	)
+	switch part := resp.Part; {
+	case part == nil:
+		http.Error(w, "Artifact is empty", http.StatusNotFound)
+		return
+	case part.InlineData != nil:
+		data = part.InlineData.Data
+		mimeType = part.InlineData.MIMEType
+	default:
+		data = []byte(part.Text)
+		mimeType = "text/plain; charset=utf-8"
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	// The type comes from the uploader, so only types that can't run script
+	// are shown inline and nothing is sniffed
+	disposition := "attachment"
+	if inlineArtifactType(mimeType) {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// inlineArtifactTypes are the media types downloads may be displayed inline as
+var inlineArtifactTypes = map[string]bool{
+	"text/plain":      true,
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"audio/mpeg":      true,
+	"audio/wav":       true,
+	"audio/ogg":       true,
+	"video/mp4":       true,
+	"video/webm":      true,
+	"application/pdf": true,
+}
+
+// inlineArtifactType reports whether an artifact of the given type is safe
+// to display in the browser
+func inlineArtifactType(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	return err == nil && inlineArtifactTypes[mediaType]
+}
+
+// handleUpload stores every multipart "file" field as a new artifact
+// version. Nothing is saved unless every file is valid.
+func (h *ArtifactHandler) handleUpload(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
+	if err := r.ParseMultipartForm(h.maxUploadSize); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid upload: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	files := r.MultipartForm.File["file"]
+	if len(files) == 0 {
+		http.Error(w, "No file provided", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	userID := h.userID(r)
+	threadID := r.PathValue("threadID")
+
+	// Read and validate every file first, so a bad one doesn't leave the
+	// files before it saved
+	reqs := make([]*artifact.SaveRequest, 0, len(files))
+	for _, fh := range files {
+		f, err := fh.Open()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read upload: %v", err), http.StatusBadRequest)
+			return
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read upload: %v", err), http.StatusBadRequest)
+			return
+		}
+		if len(data) == 0 {
+			http.Error(w, fmt.Sprintf("Empty file: %s", fh.Filename), http.StatusBadRequest)
+			return
+		}
+
+		mimeType := fh.Header.Get("Content-Type")
+		if mimeType == "" {
+			mimeType = http.DetectContentType(data)
+		}
+
+		req := &artifact.SaveRequest{
+			AppName:   h.appName,
+			UserID:    userID,
+			SessionID: threadID,
+			FileName:  fh.Filename,
+			Part:      genai.NewPartFromBytes(data, mimeType),
+		}
+		if err := validateArtifactRequest(req); err != nil {
+			writeArtifactError(w, err)
+			return
+		}
+		reqs = append(reqs, req)
+	}
+
+	saved := make([]ArtifactInfo, 0, len(reqs))
+	for _, req := range reqs {
+		resp, err := h.service.Save(ctx, req)
+		if err != nil {
+			writeArtifactError(w, err)
+			return
+		}
+		saved = append(saved, ArtifactInfo{Name: req.FileName, Versions: []int64{resp.Version}})
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"threadId":  threadID,
+		"artifacts": saved,
+	})
+}
+
+// errInvalidArtifactRequest marks requests the artifact service would reject
+var errInvalidArtifactRequest = errors.New("invalid artifact request")
+
+// validateArtifactRequest checks a request before it reaches the service, so
+// invalid input is told apart from service failures
+func validateArtifactRequest(req interface{ Validate() error }) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidArtifactRequest, err)
+	}
+	return nil
+}
+
+// writeArtifactError maps artifact service errors to HTTP status codes
+func writeArtifactError(w http.ResponseWriter, err error) {
+	if errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, "Artifact not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errInvalidArtifactRequest) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, fmt.Sprintf("Artifact service error: %v", err), http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
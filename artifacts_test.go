@@ -0,0 +1,208 @@
+package aguigo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+)
+
+func TestArtifactHandler(t *testing.T) {
+	svc := artifact.InMemoryService()
+	handler := NewArtifactHandler(svc, "test-app").WithUserID(UserIDHeader("X-User-ID"))
+
+	ctx := context.Background()
+	_, err := svc.Save(ctx, &artifact.SaveRequest{
+		AppName:   "test-app",
+		UserID:    "default-user",
+		SessionID: "thread-1",
+		FileName:  "report.txt",
+		Part:      genai.NewPartFromBytes([]byte("v1"), "text/plain"),
+	})
+	require.NoError(t, err)
+	_, err = svc.Save(ctx, &artifact.SaveRequest{
+		AppName:   "test-app",
+		UserID:    "default-user",
+		SessionID: "thread-1",
+		FileName:  "report.txt",
+		Part:      genai.NewPartFromBytes([]byte("v2"), "text/plain"),
+	})
+	require.NoError(t, err)
+
+	t.Run("List artifacts", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/thread-1", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp struct {
+			Artifacts []ArtifactInfo `json:"artifacts"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Artifacts, 1)
+		assert.Equal(t, "report.txt", resp.Artifacts[0].Name)
+		assert.ElementsMatch(t, []int64{1, 2}, resp.Artifacts[0].Versions)
+	})
+
+	t.Run("Download latest version", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/thread-1/report.txt", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/plain", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `inline; filename=report.txt`, rr.Header().Get("Content-Disposition"))
+		assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "v2", rr.Body.String())
+	})
+
+	t.Run("Download active content as attachment", func(t *testing.T) {
+		for _, mimeType := range []string{"text/html", "image/svg+xml", "application/octet-stream"} {
+			_, err := svc.Save(ctx, &artifact.SaveRequest{
+				AppName:   "test-app",
+				UserID:    "default-user",
+				SessionID: "thread-1",
+				FileName:  "page",
+				Part:      genai.NewPartFromBytes([]byte("<script>alert(1)</script>"), mimeType),
+			})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/thread-1/page", nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, `attachment; filename=page`, rr.Header().Get("Content-Disposition"), mimeType)
+			assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+		}
+	})
+
+	t.Run("Download specific version", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/thread-1/report.txt?version=1", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "v1", rr.Body.String())
+	})
+
+	t.Run("Invalid version", func(t *testing.T) {
+		for _, v := range []string{"0", "-1", "latest"} {
+			req := httptest.NewRequest(http.MethodGet, "/thread-1/report.txt?version="+v, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, "version=%s", v)
+		}
+	})
+
+	t.Run("Missing artifact", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/thread-1/missing.txt", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Scoped by user", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/thread-1/report.txt", nil)
+		req.Header.Set("X-User-ID", "someone-else")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Upload file", func(t *testing.T) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile("file", "notes.md")
+		require.NoError(t, err)
+		fw.Write([]byte("# Notes"))
+		require.NoError(t, mw.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/thread-2", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.Header.Set("X-User-ID", "user-1")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		loaded, err := svc.Load(ctx, &artifact.LoadRequest{
+			AppName:   "test-app",
+			UserID:    "user-1",
+			SessionID: "thread-2",
+			FileName:  "notes.md",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, loaded.Part.InlineData)
+		assert.Equal(t, []byte("# Notes"), loaded.Part.InlineData.Data)
+	})
+
+	t.Run("Upload with an invalid file saves nothing", func(t *testing.T) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile("file", "first.md")
+		require.NoError(t, err)
+		fw.Write([]byte("# First"))
+		_, err = mw.CreateFormFile("file", "empty.md")
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/thread-3", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		resp, err := svc.List(ctx, &artifact.ListRequest{AppName: "test-app", UserID: "default-user", SessionID: "thread-3"})
+		require.NoError(t, err)
+		assert.Empty(t, resp.FileNames)
+	})
+
+	t.Run("Upload without file", func(t *testing.T) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		require.NoError(t, mw.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/thread-2", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestWriteArtifactError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"invalid request", validateArtifactRequest(&artifact.LoadRequest{AppName: "test-app"}), http.StatusBadRequest},
+		{"not found", fmt.Errorf("artifact not found: %w", fs.ErrNotExist), http.StatusNotFound},
+		{"service failure", errors.New("bucket unavailable"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.err)
+			rr := httptest.NewRecorder()
+			writeArtifactError(rr, tt.err)
+			assert.Equal(t, tt.status, rr.Code)
+		})
+	}
+}
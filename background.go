@@ -0,0 +1,90 @@
+package aguigo
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// Run states reported by RunStatus
+const (
+	RunStateRunning  = "running"
+	RunStateFinished = "finished"
+	RunStateFailed   = "error"
+)
+
+// RunStatus describes the progress of a run buffered in a StreamStore
+type RunStatus struct {
+	RunInfo
+	State       string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	LastEventID int64      `json:"lastEventId"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+// serveBackgroundRun starts a detached run and answers right away with its
+// status. Clients follow the run through the StreamStore endpoints.
+func serveBackgroundRun(w http.ResponseWriter, r *http.Request, store *StreamStore, runs *runScope, hctx HandlerContext, input RunAgentInput, stream func(HandlerContext, func([]events.Event) bool)) {
+	rs, ok := store.create(runInfo(hctx), input)
+	if !ok {
+		http.Error(w, "Run already exists", http.StatusConflict)
+		return
+	}
+	runDetached(r, store, runs, hctx, rs, stream)
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	writeJSON(w, http.StatusAccepted, rs.status())
+}
+
+// ServeHTTP serves the status and events of buffered runs. Routes are
+// relative to the mount point:
+//
+//	GET /{threadId}/{runId}         run status
+//	GET /{threadId}/{runId}/events  SSE stream of the run (snapshot, or Last-Event-ID to resume)
+//
+// Like RunRegistry, only runs without a user or owned by the caller are
+// visible; see WithUserID.
+func (s *StreamStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization, X-User-ID, Last-Event-ID")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleStatus reports a run's status
+func (s *StreamStore) handleStatus(w http.ResponseWriter, r *http.Request) {
+	rs, ok := s.lookup(r)
+	if !ok {
+		http.Error(w, ErrRunNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, rs.status())
+}
+
+// handleEvents streams a run's events. Without Last-Event-ID the client
+// joins with a snapshot of the run so far; Last-Event-ID 0 replays the run
+// from its first event.
+func (s *StreamStore) handleEvents(w http.ResponseWriter, r *http.Request) {
+	rs, ok := s.lookup(r)
+	if !ok {
+		http.Error(w, ErrRunNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	rs.ServeHTTP(w, r)
+}
+
+// lookup returns the run addressed by the request if the caller may see it
+func (s *StreamStore) lookup(r *http.Request) (*runStream, bool) {
+	rs, ok := s.get(r.PathValue("threadID"), r.PathValue("runID"))
+	if !ok || !ownsRun(s.userID, r, rs.info) {
+		return nil, false
+	}
+	return rs, true
+}
@@ -0,0 +1,116 @@
+package aguigo
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// getStatus fetches a run's status from a StreamStore
+func getStatus(t *testing.T, store *StreamStore, threadID, runID string) (int, RunStatus) {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	store.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/"+threadID+"/"+runID, nil))
+
+	var status RunStatus
+	if rr.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	}
+	return rr.Code, status
+}
+
+func TestHandler_BackgroundRuns(t *testing.T) {
+	gate := make(chan struct{})
+	handler := New(Config{
+		BackgroundRuns: true,
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return func(yield func(events.Event, error) bool) {
+				<-gate
+				yield(events.NewCustomEvent("done"), nil)
+			}
+		}),
+	})
+	store := handler.Streams()
+	require.NotNil(t, store)
+
+	body := `{"threadId":"thread-1","runId":"run-1"}`
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	var started RunStatus
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &started))
+	assert.Equal(t, "run-1", started.RunID)
+	assert.Equal(t, RunStateRunning, started.State)
+
+	code, status := getStatus(t, store, "thread-1", "run-1")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, RunStateRunning, status.State)
+
+	close(gate)
+
+	// Any number of clients can replay the run
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/thread-1/run-1/events", nil)
+		req.Header.Set("Last-Event-ID", "0")
+		rr := httptest.NewRecorder()
+		store.ServeHTTP(rr, req)
+		assert.Equal(t, []string{"RUN_STARTED", "CUSTOM", "RUN_FINISHED"}, frameTypes(readSSEFrames(t, rr.Body, nil)))
+	}
+
+	// A client without Last-Event-ID catches up from a snapshot
+	rr = httptest.NewRecorder()
+	store.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/thread-1/run-1/events", nil))
+	frames := readSSEFrames(t, rr.Body, nil)
+	assert.Equal(t, []string{"RUN_STARTED", "MESSAGES_SNAPSHOT", "RUN_FINISHED"}, frameTypes(frames))
+	assert.Equal(t, "3", frames[0].ID)
+
+	code, status = getStatus(t, store, "thread-1", "run-1")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, RunStateFinished, status.State)
+	assert.Equal(t, int64(3), status.LastEventID)
+	assert.NotNil(t, status.FinishedAt)
+
+	code, _ = getStatus(t, store, "thread-1", "missing")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestADKHandler_BackgroundRuns(t *testing.T) {
+	handler := newTestADKHandler(t, []model.LLMResponse{
+		{Content: genai.NewContentFromText("Done researching", genai.RoleModel)},
+	}, WithBackgroundRuns(true))
+
+	body := `{"threadId":"thread-1","runId":"run-1","messages":[{"id":"m1","role":"user","content":"Research this"}]}`
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	require.Equal(t, http.StatusAccepted, rr.Code)
+
+	// Nobody subscribes; the run still completes and is saved in the session
+	require.Eventually(t, func() bool {
+		status, ok := handler.Streams().Status("thread-1", "run-1")
+		return ok && status.State == RunStateFinished
+	}, time.Second, 5*time.Millisecond)
+
+	resp, err := handler.sessionService.Get(context.Background(), &session.GetRequest{AppName: "test-app", UserID: "default-user", SessionID: "thread-1"})
+	require.NoError(t, err)
+	var texts []string
+	for evt := range resp.Session.Events().All() {
+		if evt.Content != nil && len(evt.Content.Parts) > 0 {
+			texts = append(texts, evt.Content.Parts[0].Text)
+		}
+	}
+	assert.Equal(t, []string{"Research this", "Done researching"}, texts)
+}
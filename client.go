@@ -0,0 +1,385 @@
+package aguigo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// StatusError is returned when an AG-UI endpoint answers with an HTTP error
+type StatusError struct {
+	StatusCode int
+	// Message is the body of the response
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("agent endpoint returned %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// ToolResult is the outcome of a tool call the client ran itself
+type ToolResult struct {
+	ToolCallID string
+	Content    string
+}
+
+// ToolResultMessage builds the tool message that answers a tool call
+func ToolResultMessage(toolCallID, content string) Message {
+	return Message{
+		ID:         events.GenerateMessageID(),
+		Role:       "tool",
+		Content:    []ContentPart{{Type: "text", Text: content}},
+		ToolCallID: toolCallID,
+	}
+}
+
+// Client runs agents served over AG-UI and decodes their event streams
+type Client struct {
+	endpoint       string
+	httpClient     *http.Client
+	header         http.Header
+	accept         string
+	maxReconnects  int
+	reconnectDelay time.Duration
+}
+
+// NewClient creates a client for the AG-UI endpoint at endpoint. It asks
+// for SSE and does not reconnect unless configured to.
+func NewClient(endpoint string) *Client {
+	return &Client{
+		endpoint:       endpoint,
+		httpClient:     http.DefaultClient,
+		header:         make(http.Header),
+		accept:         ContentTypeSSE,
+		reconnectDelay: time.Second,
+	}
+}
+
+// WithHTTPClient sets the HTTP client requests are sent with
+func (c *Client) WithHTTPClient(hc *http.Client) *Client {
+	if hc != nil {
+		c.httpClient = hc
+	}
+	return c
+}
+
+// WithHeader adds a header to every request, such as Authorization or X-User-ID
+func (c *Client) WithHeader(key, value string) *Client {
+	c.header.Add(key, value)
+	return c
+}
+
+// WithFormat sets the response format to ask for: ContentTypeSSE,
+// ContentTypeNDJSON or ContentTypeJSON
+func (c *Client) WithFormat(contentType string) *Client {
+	c.accept = contentType
+	return c
+}
+
+// WithReconnect lets an SSE stream that drops before the run ends reconnect
+// up to attempts times, resuming after the last event with Last-Event-ID.
+// The server's retry field overrides delay. Resuming needs a server with a
+// StreamStore.
+func (c *Client) WithReconnect(attempts int, delay time.Duration) *Client {
+	c.maxReconnects = attempts
+	if delay > 0 {
+		c.reconnectDelay = delay
+	}
+	return c
+}
+
+// Run starts a run and yields its events until the run ends, ctx is done or
+// the stream fails. An event that can't be decoded is yielded as an error
+// and the stream goes on; any other error ends it. Breaking out of the loop
+// closes the connection. Missing thread and run IDs are generated, so a
+// reconnect can find the run.
+func (c *Client) Run(ctx context.Context, input RunAgentInput) iter.Seq2[events.Event, error] {
+	return c.run(ctx, input, nil)
+}
+
+// run is Run with headers added to this run's requests. They replace the
+// client's static headers of the same name.
+func (c *Client) run(ctx context.Context, input RunAgentInput, header http.Header) iter.Seq2[events.Event, error] {
+	if input.ThreadID == "" {
+		input.ThreadID = events.GenerateThreadID()
+	}
+	if input.RunID == "" {
+		input.RunID = events.GenerateRunID()
+	}
+
+	return func(yield func(events.Event, error) bool) {
+		stream := &clientStream{yield: yield}
+		for attempt := 0; ; attempt++ {
+			err := c.runOnce(ctx, input, header, stream)
+			if err == nil || stream.stopped || stream.ended {
+				return
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				yield(nil, ctxErr)
+				return
+			}
+
+			// Only a dropped SSE stream that can be resumed is retried
+			var statusErr *StatusError
+			if stream.lastID == "" || attempt >= c.maxReconnects || errors.As(err, &statusErr) {
+				yield(nil, err)
+				return
+			}
+
+			delay := c.reconnectDelay
+			if stream.retry > 0 {
+				delay = stream.retry
+			}
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				yield(nil, ctx.Err())
+				return
+			}
+		}
+	}
+}
+
+// SendToolResults continues a thread after the client has run the tool
+// calls a run asked for. The new run's messages are input's, followed by
+// the messages of conv that input doesn't have and a tool message per result.
+func (c *Client) SendToolResults(ctx context.Context, input RunAgentInput, conv Conversation, results ...ToolResult) iter.Seq2[events.Event, error] {
+	next := input
+	next.RunID = ""
+	if next.ThreadID == "" {
+		next.ThreadID = conv.ThreadID
+	}
+
+	known := make(map[string]bool, len(input.Messages))
+	for _, m := range input.Messages {
+		known[m.ID] = true
+	}
+	next.Messages = append([]Message(nil), input.Messages...)
+	for _, m := range conv.Messages {
+		if !known[m.ID] {
+			next.Messages = append(next.Messages, m.message())
+		}
+	}
+	for _, res := range results {
+		next.Messages = append(next.Messages, ToolResultMessage(res.ToolCallID, res.Content))
+	}
+
+	return c.Run(ctx, next)
+}
+
+// clientStream tracks one run's stream across reconnects
+type clientStream struct {
+	yield   func(events.Event, error) bool
+	lastID  string
+	retry   time.Duration
+	ended   bool
+	stopped bool
+}
+
+// emit hands an event to the caller and reports whether to keep reading
+func (s *clientStream) emit(evt events.Event, err error) bool {
+	if !s.yield(evt, err) {
+		s.stopped = true
+		return false
+	}
+	switch evt.(type) {
+	case *events.RunFinishedEvent, *events.RunErrorEvent:
+		s.ended = true
+		return false
+	}
+	return true
+}
+
+// runOnce makes one request and reads its response
+func (c *Client) runOnce(ctx context.Context, input RunAgentInput, header http.Header, stream *clientStream) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for _, h := range []http.Header{c.header, header} {
+		for key, values := range h {
+			req.Header[key] = append([]string(nil), values...)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", c.accept)
+	if stream.lastID != "" {
+		req.Header.Set("Last-Event-ID", stream.lastID)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &StatusError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case ContentTypeSSE:
+		err = readSSEStream(resp.Body, stream)
+	case ContentTypeNDJSON:
+		err = readJSONStream(resp.Body, false, stream)
+	case ContentTypeJSON:
+		err = readJSONStream(resp.Body, true, stream)
+	default:
+		return fmt.Errorf("unsupported response content type %q", mediaType)
+	}
+	if err == nil && !stream.ended && !stream.stopped {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// readSSEStream reads SSE messages, remembering the last event ID and the
+// server's reconnect delay
+func readSSEStream(r io.Reader, stream *clientStream) error {
+	br := bufio.NewReader(r)
+	var data bytes.Buffer
+	var id string
+	hasID := false
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && (line == "" || err != io.EOF) {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if hasID {
+				stream.lastID = id
+			}
+			if data.Len() > 0 {
+				evt, decodeErr := decodeJSONEvent(bytes.TrimSuffix(data.Bytes(), []byte("\n")))
+				if !stream.emit(evt, decodeErr) {
+					return nil
+				}
+			}
+			data.Reset()
+			id, hasID = "", false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			id, hasID = value, true
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				stream.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// readJSONStream reads events from a JSON array or from NDJSON
+func readJSONStream(r io.Reader, array bool, stream *clientStream) error {
+	dec := json.NewDecoder(r)
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		evt, err := decodeJSONEvent(raw)
+		if !stream.emit(evt, err) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// clientEventTypes creates the event for each type the client decodes
+var clientEventTypes = map[events.EventType]func() events.Event{
+	events.EventTypeRunStarted:                 func() events.Event { return &events.RunStartedEvent{} },
+	events.EventTypeRunFinished:                func() events.Event { return &events.RunFinishedEvent{} },
+	events.EventTypeRunError:                   func() events.Event { return &events.RunErrorEvent{} },
+	events.EventTypeStepStarted:                func() events.Event { return &events.StepStartedEvent{} },
+	events.EventTypeStepFinished:               func() events.Event { return &events.StepFinishedEvent{} },
+	events.EventTypeTextMessageStart:           func() events.Event { return &events.TextMessageStartEvent{} },
+	events.EventTypeTextMessageContent:         func() events.Event { return &events.TextMessageContentEvent{} },
+	events.EventTypeTextMessageEnd:             func() events.Event { return &events.TextMessageEndEvent{} },
+	events.EventTypeTextMessageChunk:           func() events.Event { return &events.TextMessageChunkEvent{} },
+	events.EventTypeToolCallStart:              func() events.Event { return &events.ToolCallStartEvent{} },
+	events.EventTypeToolCallArgs:               func() events.Event { return &events.ToolCallArgsEvent{} },
+	events.EventTypeToolCallEnd:                func() events.Event { return &events.ToolCallEndEvent{} },
+	events.EventTypeToolCallChunk:              func() events.Event { return &events.ToolCallChunkEvent{} },
+	events.EventTypeToolCallResult:             func() events.Event { return &events.ToolCallResultEvent{} },
+	events.EventTypeStateSnapshot:              func() events.Event { return &events.StateSnapshotEvent{} },
+	events.EventTypeStateDelta:                 func() events.Event { return &events.StateDeltaEvent{} },
+	events.EventTypeMessagesSnapshot:           func() events.Event { return &events.MessagesSnapshotEvent{} },
+	events.EventTypeActivitySnapshot:           func() events.Event { return &events.ActivitySnapshotEvent{} },
+	events.EventTypeActivityDelta:              func() events.Event { return &events.ActivityDeltaEvent{} },
+	events.EventTypeThinkingStart:              func() events.Event { return &events.ThinkingStartEvent{} },
+	events.EventTypeThinkingEnd:                func() events.Event { return &events.ThinkingEndEvent{} },
+	events.EventTypeThinkingTextMessageStart:   func() events.Event { return &events.ThinkingTextMessageStartEvent{} },
+	events.EventTypeThinkingTextMessageContent: func() events.Event { return &events.ThinkingTextMessageContentEvent{} },
+	events.EventTypeThinkingTextMessageEnd:     func() events.Event { return &events.ThinkingTextMessageEndEvent{} },
+	events.EventTypeRaw:                        func() events.Event { return &events.RawEvent{} },
+	events.EventTypeCustom:                     func() events.Event { return &events.CustomEvent{} },
+}
+
+// eventDecodeError is yielded for an event the client can't decode; the
+// stream goes on after it
+type eventDecodeError struct {
+	err error
+}
+
+func (e *eventDecodeError) Error() string { return e.err.Error() }
+
+func (e *eventDecodeError) Unwrap() error { return e.err }
+
+// decodeJSONEvent decodes one event from its JSON form
+func decodeJSONEvent(data []byte) (events.Event, error) {
+	var base struct {
+		Type events.EventType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, &eventDecodeError{fmt.Errorf("decoding event: %w", err)}
+	}
+	newEvent, ok := clientEventTypes[base.Type]
+	if !ok {
+		return nil, &eventDecodeError{fmt.Errorf("decoding event: unknown event type %q", base.Type)}
+	}
+	evt := newEvent()
+	if err := json.Unmarshal(data, evt); err != nil {
+		return nil, &eventDecodeError{fmt.Errorf("decoding %s event: %w", base.Type, err)}
+	}
+	return evt, nil
+}
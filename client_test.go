@@ -0,0 +1,214 @@
+package aguigo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Run(t *testing.T) {
+	handler := New(Config{
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return func(yield func(events.Event, error) bool) {
+				for evt, err := range textSeq("m1", "Hello", " world") {
+					if !yield(evt, err) {
+						return
+					}
+				}
+				yield(events.NewThinkingStartEvent(), nil)
+				yield(events.NewThinkingEndEvent(), nil)
+			}
+		}),
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	for _, format := range []string{ContentTypeSSE, ContentTypeNDJSON, ContentTypeJSON} {
+		t.Run(format, func(t *testing.T) {
+			client := NewClient(srv.URL).WithFormat(format)
+			evts, errs := collectRun(client.Run(context.Background(), RunAgentInput{ThreadID: "thread-1", RunID: "run-1"}))
+			require.Empty(t, errs)
+
+			assert.Equal(t, []events.EventType{
+				events.EventTypeRunStarted,
+				events.EventTypeTextMessageStart,
+				events.EventTypeTextMessageContent,
+				events.EventTypeTextMessageContent,
+				events.EventTypeTextMessageEnd,
+				events.EventTypeThinkingStart,
+				events.EventTypeThinkingEnd,
+				events.EventTypeRunFinished,
+			}, eventTypes(evts))
+			assert.Equal(t, " world", evts[3].(*events.TextMessageContentEvent).Delta)
+			assert.Equal(t, "run-1", evts[7].(*events.RunFinishedEvent).RunIDValue)
+		})
+	}
+
+	t.Run("http errors", func(t *testing.T) {
+		client := NewClient(srv.URL).WithFormat("text/html")
+		_, errs := collectRun(client.Run(context.Background(), RunAgentInput{}))
+		require.Len(t, errs, 1)
+
+		var statusErr *StatusError
+		require.ErrorAs(t, errs[0], &statusErr)
+		assert.Equal(t, http.StatusNotAcceptable, statusErr.StatusCode)
+		assert.Contains(t, statusErr.Message, "supported types")
+	})
+}
+
+func TestClient_Cancel(t *testing.T) {
+	handler := New(Config{
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return func(yield func(events.Event, error) bool) {
+				if yield(events.NewCustomEvent("working"), nil) {
+					<-ctx.Done()
+				}
+			}
+		}),
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var evts []events.Event
+	var lastErr error
+	for evt, err := range NewClient(srv.URL).Run(ctx, RunAgentInput{}) {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		evts = append(evts, evt)
+		if evt.Type() == events.EventTypeCustom {
+			cancel()
+		}
+	}
+	assert.Equal(t, []events.EventType{events.EventTypeRunStarted, events.EventTypeCustom}, eventTypes(evts))
+	assert.ErrorIs(t, lastErr, context.Canceled)
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	var lastEventIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		w.Header().Set("Content-Type", ContentTypeSSE)
+
+		if len(lastEventIDs) == 1 {
+			// The first connection drops after two events
+			io.WriteString(w, "retry: 1\n\n")
+			io.WriteString(w, "id: 1\ndata: {\"type\":\"RUN_STARTED\",\"threadId\":\"t\",\"runId\":\"r\"}\n\n")
+			io.WriteString(w, ": ping\n\n")
+			io.WriteString(w, "id: 2\ndata: {\"type\":\"CUSTOM\",\"name\":\"a\"}\n\n")
+			return
+		}
+		io.WriteString(w, "id: 3\ndata: {\"type\":\"NOT_A_TYPE\"}\n\n")
+		io.WriteString(w, "id: 4\ndata: {\"type\":\"RUN_FINISHED\",\"threadId\":\"t\",\"runId\":\"r\"}\n\n")
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL).WithReconnect(1, 0)
+	evts, errs := collectRun(client.Run(context.Background(), RunAgentInput{ThreadID: "t", RunID: "r"}))
+
+	assert.Equal(t, []string{"", "2"}, lastEventIDs)
+	assert.Equal(t, []events.EventType{events.EventTypeRunStarted, events.EventTypeCustom, events.EventTypeRunFinished}, eventTypes(evts))
+	// The unknown event is reported without ending the stream
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), `unknown event type "NOT_A_TYPE"`)
+
+	t.Run("without reconnects a dropped stream is an error", func(t *testing.T) {
+		lastEventIDs = nil
+		_, errs := collectRun(NewClient(srv.URL).Run(context.Background(), RunAgentInput{}))
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], io.ErrUnexpectedEOF)
+		assert.Len(t, lastEventIDs, 1)
+	})
+}
+
+func TestClient_SendToolResults(t *testing.T) {
+	var inputs []RunAgentInput
+	handler := New(Config{
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			inputs = append(inputs, input)
+			return func(yield func(events.Event, error) bool) {
+				if len(inputs) > 1 {
+					yield(events.NewCustomEvent("thanks"), nil)
+					return
+				}
+				yield(events.NewToolCallStartEvent("call-1", "get_location", events.WithParentMessageID("m1")), nil)
+				yield(events.NewToolCallArgsEvent("call-1", "{}"), nil)
+				yield(events.NewToolCallEndEvent("call-1"), nil)
+			}
+		}),
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	input := RunAgentInput{ThreadID: "thread-1", Messages: []Message{{ID: "u1", Role: "user", Content: []ContentPart{{Type: "text", Text: "Where am I?"}}}}}
+
+	evts, errs := collectRun(client.Run(context.Background(), input))
+	require.Empty(t, errs)
+	conv, err := Reduce(evts)
+	require.NoError(t, err)
+
+	pending := conv.PendingToolCalls()
+	require.Len(t, pending, 1)
+	assert.Equal(t, "get_location", pending[0].Function.Name)
+
+	evts, errs = collectRun(client.SendToolResults(context.Background(), input, conv, ToolResult{ToolCallID: pending[0].ID, Content: "Paris"}))
+	require.Empty(t, errs)
+	assert.Equal(t, events.EventTypeCustom, evts[1].Type())
+
+	require.Len(t, inputs, 2)
+	next := inputs[1]
+	assert.Equal(t, "thread-1", next.ThreadID)
+	assert.NotEqual(t, inputs[0].RunID, next.RunID)
+	require.Len(t, next.Messages, 3)
+	assert.Equal(t, "u1", next.Messages[0].ID)
+	assert.Equal(t, "assistant", next.Messages[1].Role)
+	assert.Equal(t, "call-1", next.Messages[1].ToolCalls[0].ID)
+	assert.Equal(t, "tool", next.Messages[2].Role)
+	assert.Equal(t, "call-1", next.Messages[2].ToolCallID)
+	assert.Equal(t, "Paris", next.Messages[2].Content[0].Text)
+}
+
+func TestMessage_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		msg  Message
+		want string
+	}{
+		{
+			msg:  Message{ID: "m1", Role: "user", Content: []ContentPart{{Type: "text", Text: "Hi"}}},
+			want: `{"id":"m1","role":"user","content":"Hi"}`,
+		},
+		{
+			msg:  Message{ID: "m1", Role: "user", Content: []ContentPart{{Type: "text", Text: "Look"}, {Type: "image", URL: "https://example.com/a.png"}}},
+			want: `{"id":"m1","role":"user","content":[{"type":"text","text":"Look"},{"type":"image","url":"https://example.com/a.png"}]}`,
+		},
+		{
+			msg:  ToolResultMessage("call-1", "ok"),
+			want: `"role":"tool","toolCallId":"call-1","content":"ok"}`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			data, err := json.Marshal(tt.msg)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), tt.want)
+
+			var back Message
+			require.NoError(t, json.Unmarshal(data, &back))
+			assert.Equal(t, tt.msg, back)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package aguigo
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/runner"
+)
+
+// AgentFactory builds the agent for one run, from the request, the user and
+// the run input, such as the tools the frontend declares
+type AgentFactory func(ctx HandlerContext, input RunAgentInput) (agent.Agent, error)
+
+// AgentKeyFunc returns the key a run's runner is cached by. Runs with the
+// same key share a runner and the agent the factory built for the first of
+// them; an empty key builds a new agent for the run.
+type AgentKeyFunc func(ctx HandlerContext, input RunAgentInput) string
+
+// defaultAgentCacheSize is how many runners an ADKHandler keeps by default
+const defaultAgentCacheSize = 256
+
+// runnerCache builds and caches the runners of an ADKHandler. It keeps the
+// size most recently used keys and drops the least recently used beyond that.
+type runnerCache struct {
+	newRunner func(ag agent.Agent) (*runner.Runner, error)
+	factory   AgentFactory
+	key       AgentKeyFunc
+	size      int
+
+	mu      sync.Mutex
+	runners map[string]*list.Element
+	// recent orders the cached keys, most recently used first
+	recent *list.List
+}
+
+// cachedRunner is an entry of runnerCache.recent
+type cachedRunner struct {
+	key    string
+	runner *runner.Runner
+}
+
+func newRunnerCache(newRunner func(ag agent.Agent) (*runner.Runner, error), factory AgentFactory, key AgentKeyFunc, size int) *runnerCache {
+	if size <= 0 {
+		size = defaultAgentCacheSize
+	}
+	return &runnerCache{
+		newRunner: newRunner,
+		factory:   factory,
+		key:       key,
+		size:      size,
+		runners:   make(map[string]*list.Element),
+		recent:    list.New(),
+	}
+}
+
+// get returns the runner for a run, building its agent if it isn't cached
+func (c *runnerCache) get(hctx HandlerContext, input RunAgentInput) (*runner.Runner, error) {
+	var key string
+	if c.key != nil {
+		key = c.key(hctx, input)
+	}
+	if key != "" {
+		if r, ok := c.lookup(key); ok {
+			return r, nil
+		}
+	}
+
+	ag, err := c.factory(hctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build agent: %w", err)
+	}
+	r, err := c.newRunner(ag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runner: %w", err)
+	}
+	if key == "" {
+		return r, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// A concurrent run may have built the same key first
+	if elem, ok := c.runners[key]; ok {
+		c.recent.MoveToFront(elem)
+		return elem.Value.(*cachedRunner).runner, nil
+	}
+	c.runners[key] = c.recent.PushFront(&cachedRunner{key: key, runner: r})
+	for c.recent.Len() > c.size {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.runners, oldest.Value.(*cachedRunner).key)
+	}
+	return r, nil
+}
+
+// lookup returns the runner cached under key and marks it recently used
+func (c *runnerCache) lookup(key string) (*runner.Runner, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.runners[key]
+	if !ok {
+		return nil, false
+	}
+	c.recent.MoveToFront(elem)
+	return elem.Value.(*cachedRunner).runner, true
+}
+
+// evict drops the runner cached under key
+func (c *runnerCache) evict(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.runners[key]; ok {
+		c.recent.Remove(elem)
+		delete(c.runners, key)
+	}
+}
@@ -0,0 +1,115 @@
+package aguigo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+)
+
+func TestADKHandler_AgentFactory(t *testing.T) {
+	var builds atomic.Int32
+	var tools []string
+	factory := func(ctx HandlerContext, input RunAgentInput) (agent.Agent, error) {
+		tenant := ctx.Request.Header.Get("X-Tenant")
+		if tenant == "" {
+			return nil, errors.New("no tenant")
+		}
+		builds.Add(1)
+		tools = tools[:0]
+		for _, tool := range input.Tools {
+			tools = append(tools, tool.Name)
+		}
+		return newEchoAgent(t, tenant), nil
+	}
+	key := func(ctx HandlerContext, input RunAgentInput) string {
+		return ctx.Request.Header.Get("X-Tenant")
+	}
+
+	run := func(t *testing.T, h http.Handler, tenant string) RunResult {
+		t.Helper()
+		body := `{"threadId":"thread-` + tenant + `","messages":[{"id":"u1","role":"user","content":"Hi"}],"tools":[{"name":"lookup"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Accept", ContentTypeResult)
+		req.Header.Set("X-Tenant", tenant)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		var res RunResult
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		return res
+	}
+	answer := func(t *testing.T, res RunResult) string {
+		t.Helper()
+		require.Equal(t, RunStateFinished, res.Status, res.Error)
+		require.Len(t, res.Messages, 1)
+		return *res.Messages[0].Content
+	}
+
+	t.Run("cached by key", func(t *testing.T) {
+		builds.Store(0)
+		handler, err := NewADKHandler(nil, session.InMemoryService(), "test-app", WithAgentFactory(factory, key))
+		require.NoError(t, err)
+
+		assert.Equal(t, "acme: Hi", answer(t, run(t, handler, "acme")))
+		assert.Equal(t, []string{"lookup"}, tools)
+		assert.Equal(t, "acme: Hi", answer(t, run(t, handler, "acme")))
+		assert.Equal(t, "globex: Hi", answer(t, run(t, handler, "globex")))
+		assert.Equal(t, int32(2), builds.Load())
+
+		handler.EvictAgent("acme")
+		assert.Equal(t, "acme: Hi", answer(t, run(t, handler, "acme")))
+		assert.Equal(t, int32(3), builds.Load())
+	})
+
+	t.Run("without key", func(t *testing.T) {
+		builds.Store(0)
+		handler, err := NewADKHandler(nil, session.InMemoryService(), "test-app", WithAgentFactory(factory, nil))
+		require.NoError(t, err)
+
+		answer(t, run(t, handler, "acme"))
+		answer(t, run(t, handler, "acme"))
+		assert.Equal(t, int32(2), builds.Load())
+	})
+
+	t.Run("drops the least recently used runner", func(t *testing.T) {
+		builds.Store(0)
+		handler, err := NewADKHandler(nil, session.InMemoryService(), "test-app", WithAgentFactory(factory, key), WithAgentCacheSize(2))
+		require.NoError(t, err)
+
+		answer(t, run(t, handler, "acme"))
+		answer(t, run(t, handler, "globex"))
+		answer(t, run(t, handler, "acme"))
+		// initech takes globex's place, the least recently used
+		answer(t, run(t, handler, "initech"))
+		assert.Equal(t, int32(3), builds.Load())
+		assert.Equal(t, 2, handler.agents.recent.Len())
+
+		answer(t, run(t, handler, "acme"))
+		assert.Equal(t, int32(3), builds.Load())
+		answer(t, run(t, handler, "globex"))
+		assert.Equal(t, int32(4), builds.Load())
+	})
+
+	t.Run("factory error", func(t *testing.T) {
+		handler, err := NewADKHandler(nil, session.InMemoryService(), "test-app", WithAgentFactory(factory, key))
+		require.NoError(t, err)
+
+		res := run(t, handler, "")
+		assert.Equal(t, RunStateFailed, res.Status)
+		assert.Equal(t, "failed to build agent: no tenant", res.Error)
+	})
+
+	t.Run("agent required without factory", func(t *testing.T) {
+		_, err := NewADKHandler(nil, session.InMemoryService(), "test-app")
+		assert.Error(t, err)
+	})
+}
@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
 	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/encoding/sse"
@@ -21,6 +23,8 @@ type RunAgentInput struct {
 	Tools    []Tool    `json:"tools,omitempty"`
 	Context  any       `json:"context,omitempty"`
 	State    any       `json:"state,omitempty"`
+	// ForwardedProps are properties the client passes through to the server
+	ForwardedProps any `json:"forwardedProps,omitempty"`
 }
 
 // Tool represents a tool definition in AG-UI format
@@ -37,6 +41,10 @@ type Message struct {
 	Content   []ContentPart `json:"content"`
 	Name      string        `json:"name,omitempty"`
 	CreatedAt int64         `json:"createdAt,omitempty"`
+	// ToolCalls are the calls an assistant message made
+	ToolCalls []events.ToolCall `json:"toolCalls,omitempty"`
+	// ToolCallID is the call a tool message answers
+	ToolCallID string `json:"toolCallId,omitempty"`
 }
 
 // ContentPart represents a part of message content
@@ -48,6 +56,19 @@ type ContentPart struct {
 	URL      string `json:"url,omitempty"`
 }
 
+// MarshalJSON writes content made of a single text part as a plain string,
+// the form every AG-UI server accepts
+func (m Message) MarshalJSON() ([]byte, error) {
+	type MessageAlias Message
+	if len(m.Content) == 1 && m.Content[0] == (ContentPart{Type: "text", Text: m.Content[0].Text}) {
+		return json.Marshal(struct {
+			MessageAlias
+			Content string `json:"content"`
+		}{MessageAlias(m), m.Content[0].Text})
+	}
+	return json.Marshal(MessageAlias(m))
+}
+
 // UnmarshalJSON implements custom JSON unmarshaling for Message
 // to support both string content (simple) and array content (rich)
 func (m *Message) UnmarshalJSON(data []byte) error {
@@ -93,7 +114,11 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// EventSource is the interface that agent implementations must satisfy
+// EventSource is the interface that agent implementations must satisfy. Sources
+// that also implement IterEventSource are consumed through their iterator.
+// A panic in Run itself ends the run with RUN_ERROR, but a panic in a
+// goroutine Run starts crashes the process like any other; StreamFunc and
+// SeqFunc recover the panics of their producers.
 type EventSource interface {
 	Run(ctx HandlerContext, input RunAgentInput) <-chan events.Event
 }
@@ -104,6 +129,20 @@ type HandlerContext struct {
 	RunID    string
 	UserID   string
 	Request  *http.Request
+	// Context is cancelled when the client disconnects, the handler shuts
+	// down or the run times out. Sources should stop their work when it is done.
+	Context context.Context
+}
+
+// Done returns the run's cancellation channel, or nil if the run has no context
+func (c HandlerContext) Done() <-chan struct{} {
+	if c.Context != nil {
+		return c.Context.Done()
+	}
+	if c.Request != nil {
+		return c.Request.Context().Done()
+	}
+	return nil
 }
 
 // Config configures the handler
@@ -111,6 +150,26 @@ type Config struct {
 	EventSource EventSource
 	AppName     string
 	Logger      Logger
+	// Interceptors filter, rewrite or inject events before they are written
+	Interceptors []EventInterceptor
+	// ManualLifecycle disables the automatic RUN_STARTED/RUN_FINISHED events
+	// for sources that send them themselves. A run that fails, panics, times
+	// out or is cancelled before the source sent a terminal event still ends
+	// with RUN_ERROR.
+	ManualLifecycle bool
+	// RunTimeout cancels runs that take longer; zero means no limit
+	RunTimeout time.Duration
+	// Runs records in-flight runs so they can be cancelled by ID
+	Runs *RunRegistry
+	// Streams makes SSE runs resumable: they outlive the connection and
+	// clients reconnect with Last-Event-ID
+	Streams *StreamStore
+	// BackgroundRuns answers a POST with 202 and the run status right away;
+	// clients follow the run through the Streams endpoints. A default store
+	// is created if Streams is nil.
+	BackgroundRuns bool
+	// KeepAlive sends SSE heartbeats and a reconnect delay
+	KeepAlive SSEKeepAlive
 }
 
 // Logger interface for logging
@@ -124,9 +183,15 @@ func (defaultLogger) Printf(format string, v ...any) {}
 
 // Handler handles AG-UI protocol requests
 type Handler struct {
-	eventSource EventSource
-	appName     string
-	logger      Logger
+	eventSource  EventSource
+	appName      string
+	logger       Logger
+	interceptors interceptorChain
+	manualRun    bool
+	runs         *runScope
+	streams      *StreamStore
+	background   bool
+	keepAlive    SSEKeepAlive
 }
 
 // New creates a new AG-UI handler
@@ -136,13 +201,41 @@ func New(config Config) *Handler {
 		logger = defaultLogger{}
 	}
 
+	streams := config.Streams
+	if config.BackgroundRuns && streams == nil {
+		streams = NewStreamStore(0).WithKeepAlive(config.KeepAlive)
+	}
+
 	return &Handler{
-		eventSource: config.EventSource,
-		appName:     config.AppName,
-		logger:      logger,
+		eventSource:  config.EventSource,
+		appName:      config.AppName,
+		logger:       logger,
+		interceptors: interceptorChain(config.Interceptors),
+		manualRun:    config.ManualLifecycle,
+		runs:         newRunScope(config.RunTimeout, config.Runs),
+		streams:      streams,
+		background:   config.BackgroundRuns,
+		keepAlive:    config.KeepAlive,
 	}
 }
 
+// Streams returns the store buffering the handler's runs, or nil
+func (h *Handler) Streams() *StreamStore {
+	return h.streams
+}
+
+// WebSocket returns a handler that serves the same runs over a WebSocket
+func (h *Handler) WebSocket() *WebSocketHandler {
+	userID := func(r *http.Request) string { return r.Header.Get("X-User-ID") }
+	return newWebSocketHandler(h.runs, userID, h.streamEvents)
+}
+
+// Shutdown cancels all in-flight runs. Register it with
+// http.Server.RegisterOnShutdown so streaming runs end with the server.
+func (h *Handler) Shutdown() {
+	h.runs.shutdown()
+}
+
 // ServeHTTP handles AG-UI protocol requests
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.logger.Printf("[AG-UI] Received %s request from %s", r.Method, r.RemoteAddr)
@@ -184,61 +277,223 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Request:  r,
 	}
 
-	accept := r.Header.Get("Accept")
-	if accept == "" || accept == "text/event-stream" || accept == "*/*" {
-		h.handleSSE(w, r.Context(), ctx, input)
-	} else {
-		h.handleJSON(w, r.Context(), ctx, input)
+	if h.background {
+		serveBackgroundRun(w, r, h.streams, h.runs, ctx, input, func(hctx HandlerContext, emit func([]events.Event) bool) {
+			h.streamEvents(hctx, input, emit)
+		})
+		return
+	}
+
+	contentType, ok := negotiateResponse(w, r)
+	if !ok {
+		return
+	}
+	if contentType == ContentTypeSSE && h.streams != nil {
+		serveResumableSSE(w, r, h.streams, h.runs, ctx, input, h.keepAlive, func(hctx HandlerContext, emit func([]events.Event) bool) {
+			h.streamEvents(hctx, input, emit)
+		})
+		return
+	}
+
+	runCtx, cancel := h.runs.runContext(r.Context(), ctx)
+	defer cancel()
+	ctx.Context = runCtx
+
+	switch contentType {
+	case ContentTypeSSE:
+		h.handleSSE(w, runCtx, ctx, input)
+	case ContentTypeProto:
+		h.handleProto(w, ctx, input)
+	case ContentTypeNDJSON:
+		h.handleNDJSON(w, ctx, input)
+	case ContentTypeResult:
+		h.handleResult(w, ctx, input)
+	default:
+		h.handleJSON(w, ctx, input)
 	}
 }
 
 func (h *Handler) handleCORS(w http.ResponseWriter) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization, X-User-ID")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization, X-User-ID, Last-Event-ID")
 	w.WriteHeader(http.StatusOK)
 }
 
 func (h *Handler) handleSSE(w http.ResponseWriter, ctx context.Context, hctx HandlerContext, input RunAgentInput) {
-	w.Header().Set("Content-Type", "text/event-stream")
+	conn := startSSE(w, h.keepAlive)
+	defer conn.close()
+
+	writer := sse.NewSSEWriter()
+
+	h.streamEvents(hctx, input, func(evts []events.Event) bool {
+		for _, evt := range evts {
+			if err := conn.write(func(w io.Writer) error { return writer.WriteEvent(ctx, w, evt) }); err != nil {
+				h.logger.Printf("[AG-UI] Failed to send event: %v", err)
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// handleJSON streams the events as a JSON array, flushing after each event
+func (h *Handler) handleJSON(w http.ResponseWriter, hctx HandlerContext, input RunAgentInput) {
+	w.Header().Set("Content-Type", ContentTypeJSON)
 	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("X-Accel-Buffering", "no")
 
-	writer := sse.NewSSEWriter()
-	eventsChan := h.eventSource.Run(hctx, input)
+	array := newJSONArrayWriter(w)
+	h.streamEvents(hctx, input, func(evts []events.Event) bool {
+		for _, evt := range evts {
+			if clientGone(hctx) {
+				return false
+			}
+			if err := array.write(evt); err != nil {
+				h.logger.Printf("[AG-UI] Failed to send event: %v", err)
+				return false
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+		return true
+	})
+	array.close()
+}
 
-	for evt := range eventsChan {
-		if err := writer.WriteEvent(ctx, w, evt); err != nil {
-			h.logger.Printf("[AG-UI] Failed to send event: %v", err)
-			return
+// handleResult runs the agent to the end and answers with its outcome
+// instead of the events
+func (h *Handler) handleResult(w http.ResponseWriter, hctx HandlerContext, input RunAgentInput) {
+	collector := newRunResultCollector(input.State)
+	h.streamEvents(hctx, input, func(evts []events.Event) bool {
+		for _, evt := range evts {
+			collector.apply(evt)
 		}
-		if f, ok := w.(http.Flusher); ok {
-			f.Flush()
+		return !clientGone(hctx)
+	})
+	writeRunResult(w, collector.result(hctx))
+}
+
+// handleNDJSON streams events as newline-delimited JSON, flushing after each event
+func (h *Handler) handleNDJSON(w http.ResponseWriter, hctx HandlerContext, input RunAgentInput) {
+	w.Header().Set("Content-Type", ContentTypeNDJSON)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	h.streamEvents(hctx, input, func(evts []events.Event) bool {
+		for _, evt := range evts {
+			if clientGone(hctx) {
+				return false
+			}
+			if err := writeNDJSONEvent(w, evt); err != nil {
+				h.logger.Printf("[AG-UI] Failed to send event: %v", err)
+				return false
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
 		}
-	}
+		return true
+	})
 }
 
-func (h *Handler) handleJSON(w http.ResponseWriter, ctx context.Context, hctx HandlerContext, input RunAgentInput) {
-	var allEvents []events.Event
+// handleProto streams length-prefixed protobuf events, flushing after each event
+func (h *Handler) handleProto(w http.ResponseWriter, hctx HandlerContext, input RunAgentInput) {
+	w.Header().Set("Content-Type", ContentTypeProto)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	h.streamEvents(hctx, input, func(evts []events.Event) bool {
+		for _, evt := range evts {
+			if clientGone(hctx) {
+				return false
+			}
+			data, err := encodeProtoEvent(evt)
+			if err != nil {
+				h.logger.Printf("[AG-UI] Failed to encode event: %v", err)
+				continue
+			}
+			if err := writeProtoFrame(w, data); err != nil {
+				h.logger.Printf("[AG-UI] Failed to send event: %v", err)
+				return false
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+		return true
+	})
+}
+
+// streamEvents runs the event source, wraps its events in the run lifecycle,
+// passes them through the interceptors and hands the result to emit. It stops
+// early when emit returns false.
+func (h *Handler) streamEvents(hctx HandlerContext, input RunAgentInput, emit func([]events.Event) bool) {
+	lifecycle := newRunLifecycle(hctx, !h.manualRun)
+
+	// A run that ends flushes its interceptors before the terminal event;
+	// when the client is gone they are still flushed to release their state
+	ended := false
+	defer func() {
+		if !ended {
+			h.interceptors.Flush(hctx)
+		}
+	}()
+
+	write := func(evts []events.Event) bool {
+		for _, evt := range evts {
+			if !emit(h.interceptors.Intercept(hctx, evt)) {
+				return false
+			}
+		}
+		return true
+	}
+	end := func(evts []events.Event) {
+		ended = true
+		emit(h.interceptors.finish(hctx, evts))
+	}
 
-	eventsChan := h.eventSource.Run(hctx, input)
-	for evt := range eventsChan {
-		allEvents = append(allEvents, evt)
+	if !write(lifecycle.start()) {
+		return
 	}
 
-	var jsonEvents []json.RawMessage
-	for _, evt := range allEvents {
-		data, err := evt.ToJSON()
+	seq, err := h.startSource(hctx, input)
+	if err != nil {
+		h.logger.Printf("[AG-UI] Event source failed to start: %v", err)
+		end(lifecycle.fail(err))
+		return
+	}
+
+	for evt, err := range recoverSeq(seq) {
+		if err == nil {
+			err = runCancelled(hctx.Context)
+		}
 		if err != nil {
-			continue
+			h.logger.Printf("[AG-UI] Event source failed: %v", err)
+			write(lifecycle.fail(err))
+			return
+		}
+		if !write(lifecycle.process(evt)) {
+			return
 		}
-		jsonEvents = append(jsonEvents, data)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(jsonEvents)
+	if err := runCancelled(hctx.Context); err != nil {
+		end(lifecycle.fail(err))
+	} else {
+		end(lifecycle.finish())
+	}
+}
+
+// startSource calls the event source, turning a panic into an error
+func (h *Handler) startSource(hctx HandlerContext, input RunAgentInput) (seq iter.Seq2[events.Event, error], err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event source panicked: %v", r)
+		}
+	}()
+	return IterSource(h.eventSource).Events(hctx, input), nil
 }
 
 // HealthHandler returns a simple health check endpoint
@@ -257,7 +512,7 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization, X-User-ID")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization, X-User-ID, Last-Event-ID")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,207 @@
+package aguigo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// runJSON posts input to the handler in JSON mode and decodes the event array
+func runJSON(t *testing.T, handler http.Handler, input RunAgentInput) []map[string]any {
+	t.Helper()
+
+	body, _ := json.Marshal(input)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Accept", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	return decodeJSONEvents(t, rr.Body.Bytes())
+}
+
+// decodeJSONEvents decodes a JSON mode response body
+func decodeJSONEvents(t *testing.T, body []byte) []map[string]any {
+	t.Helper()
+
+	var evts []map[string]any
+	require.NoError(t, json.Unmarshal(body, &evts))
+	return evts
+}
+
+// jsonEventType returns the type of a single JSON encoded event
+func jsonEventType(t *testing.T, data []byte) string {
+	t.Helper()
+
+	var evt struct {
+		Type string `json:"type"`
+	}
+	require.NoError(t, json.Unmarshal(data, &evt))
+	return evt.Type
+}
+
+func jsonEventTypes(evts []map[string]any) []string {
+	types := make([]string, 0, len(evts))
+	for _, evt := range evts {
+		types = append(types, evt["type"].(string))
+	}
+	return types
+}
+
+func eventTypes(evts []events.Event) []events.EventType {
+	types := make([]events.EventType, 0, len(evts))
+	for _, evt := range evts {
+		types = append(types, evt.Type())
+	}
+	return types
+}
+
+// ndjsonTypes returns the event types of an NDJSON body
+func ndjsonTypes(t *testing.T, body string) []string {
+	t.Helper()
+
+	var types []string
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		types = append(types, jsonEventType(t, scanner.Bytes()))
+	}
+	return types
+}
+
+// sseFrame is one parsed SSE event
+type sseFrame struct {
+	ID   string
+	Type string
+}
+
+// readSSEFrames reads SSE frames from r until stop returns true or the stream ends
+func readSSEFrames(t *testing.T, r io.Reader, stop func(sseFrame) bool) []sseFrame {
+	t.Helper()
+
+	var frames []sseFrame
+	var cur sseFrame
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			cur.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			cur.Type = jsonEventType(t, []byte(strings.TrimPrefix(line, "data: ")))
+		case line == "" && cur.Type != "":
+			frames = append(frames, cur)
+			if stop != nil && stop(cur) {
+				return frames
+			}
+			cur = sseFrame{}
+		}
+	}
+	return frames
+}
+
+func frameTypes(frames []sseFrame) []string {
+	types := make([]string, 0, len(frames))
+	for _, f := range frames {
+		types = append(types, f.Type)
+	}
+	return types
+}
+
+// collectRun reads an event sequence to the end
+func collectRun(seq iter.Seq2[events.Event, error]) ([]events.Event, []error) {
+	var evts []events.Event
+	var errs []error
+	for evt, err := range seq {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		evts = append(evts, evt)
+	}
+	return evts, errs
+}
+
+// collectEvents reads an event sequence that must not fail to the end
+func collectEvents(t *testing.T, seq iter.Seq2[events.Event, error]) []events.Event {
+	t.Helper()
+
+	evts, errs := collectRun(seq)
+	require.Empty(t, errs)
+	return evts
+}
+
+func textSeq(msgID string, deltas ...string) iter.Seq2[events.Event, error] {
+	return func(yield func(events.Event, error) bool) {
+		if !yield(events.NewTextMessageStartEvent(msgID, events.WithRole("assistant")), nil) {
+			return
+		}
+		for _, delta := range deltas {
+			if !yield(events.NewTextMessageContentEvent(msgID, delta), nil) {
+				return
+			}
+		}
+		yield(events.NewTextMessageEndEvent(msgID), nil)
+	}
+}
+
+// newTestADKHandler builds an ADKHandler around a custom agent that emits the
+// given model responses, one session event per response
+func newTestADKHandler(t *testing.T, responses []model.LLMResponse, opts ...Option) *ADKHandler {
+	t.Helper()
+
+	ag, err := agent.New(agent.Config{
+		Name: "test_agent",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				for _, resp := range responses {
+					evt := session.NewEvent(ctx.InvocationID())
+					evt.Author = "test_agent"
+					evt.LLMResponse = resp
+					if !yield(evt, nil) {
+						return
+					}
+				}
+			}
+		},
+	})
+	require.NoError(t, err)
+
+	handler, err := NewADKHandler(ag, session.InMemoryService(), "test-app", opts...)
+	require.NoError(t, err)
+	return handler
+}
+
+// newEchoAgent creates an agent that answers with its name and the user's text
+func newEchoAgent(t *testing.T, name string) agent.Agent {
+	t.Helper()
+
+	ag, err := agent.New(agent.Config{
+		Name: name,
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				text := ""
+				if content := ctx.UserContent(); content != nil && len(content.Parts) > 0 {
+					text = content.Parts[0].Text
+				}
+				evt := session.NewEvent(ctx.InvocationID())
+				evt.Author = name
+				evt.Content = genai.NewContentFromText(name+": "+text, genai.RoleModel)
+				yield(evt, nil)
+			}
+		},
+	})
+	require.NoError(t, err)
+	return ag
+}
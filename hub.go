@@ -0,0 +1,430 @@
+package aguigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// defaultSubscriberQueueSize is the number of events a subscriber may fall
+// behind before the backpressure policy applies
+const defaultSubscriberQueueSize = 256
+
+// defaultSubscriberBufferLimit is the most events a subscriber queues under
+// BackpressureBuffer
+const defaultSubscriberBufferLimit = 16384
+
+// ErrSlowSubscriber ends a subscription that fell too far behind under
+// BackpressureDisconnect
+var ErrSlowSubscriber = errors.New("subscriber fell too far behind")
+
+// BackpressurePolicy decides what happens to a subscriber whose queue is full
+type BackpressurePolicy int
+
+const (
+	// BackpressureDisconnect ends the subscription with ErrSlowSubscriber
+	// once its queued events are delivered. SSE clients reconnect with
+	// Last-Event-ID and pick up where they left off.
+	BackpressureDisconnect BackpressurePolicy = iota
+	// BackpressureDrop discards events while the queue is full and resyncs
+	// the subscriber with a MESSAGES_SNAPSHOT and STATE_SNAPSHOT once it
+	// catches up
+	BackpressureDrop
+	// BackpressureBuffer queues events beyond the queue size, up to
+	// HubConfig.BufferLimit, and then drops and resyncs like BackpressureDrop
+	BackpressureBuffer
+)
+
+// HubConfig configures a Hub
+type HubConfig struct {
+	// ReplaySize is the number of recent events kept for SubscribeAfter.
+	// Defaults to 1024.
+	ReplaySize int
+	// QueueSize is the number of undelivered events a subscriber may hold
+	// before Backpressure applies. Defaults to 256.
+	QueueSize int
+	// Backpressure is the policy for subscribers with a full queue
+	Backpressure BackpressurePolicy
+	// BufferLimit is the number of undelivered events a subscriber may hold
+	// under BackpressureBuffer. Defaults to 16384.
+	BufferLimit int
+	// Messages is the conversation the run started from. It is included in
+	// the messages snapshot sent to late subscribers.
+	Messages []Message
+	// State is the state the run started from. It is the base of the state
+	// snapshot sent to late subscribers.
+	State any
+	// KeepAlive sets the heartbeat and reconnect delay of ServeHTTP
+	KeepAlive SSEKeepAlive
+}
+
+// HubEvent is an event with its per-run sequence number
+type HubEvent struct {
+	ID    int64
+	Event events.Event
+}
+
+// Hub broadcasts the events of one run to any number of subscribers. It
+// numbers events, keeps a bounded replay buffer and follows the run's
+// messages and state, so subscribers can join at any point: from the start,
+// after a known event ID, or late with a snapshot of the run so far.
+type Hub struct {
+	mu      sync.Mutex
+	cfg     HubConfig
+	replay  []HubEvent
+	start   int
+	lastSeq int64
+	closed  bool
+	subs    map[*Subscription]struct{}
+	snap    *runSnapshot
+}
+
+// NewHub creates a hub for one run
+func NewHub(cfg HubConfig) *Hub {
+	if cfg.ReplaySize <= 0 {
+		cfg.ReplaySize = defaultReplayBufferSize
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultSubscriberQueueSize
+	}
+	if cfg.BufferLimit <= 0 {
+		cfg.BufferLimit = defaultSubscriberBufferLimit
+	}
+
+	snap := newRunSnapshot()
+	snap.seed(cfg.Messages, cfg.State)
+
+	return &Hub{
+		cfg:  cfg,
+		subs: make(map[*Subscription]struct{}),
+		snap: snap,
+	}
+}
+
+// Publish numbers events and hands them to every subscriber. Events
+// published after Close are ignored.
+func (h *Hub) Publish(evts ...events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	for _, evt := range evts {
+		h.lastSeq++
+		e := HubEvent{ID: h.lastSeq, Event: evt}
+		h.replay = append(h.replay, e)
+
+		// Subscribers see the snapshot from before the event, to know
+		// what to close should they start dropping events with it
+		for sub := range h.subs {
+			if !sub.push(e) {
+				delete(h.subs, sub)
+			}
+		}
+		h.snap.apply(evt)
+	}
+
+	// Drop the oldest events beyond capacity, compacting the slice now and then
+	if n := len(h.replay) - h.start; n > h.cfg.ReplaySize {
+		h.start += n - h.cfg.ReplaySize
+	}
+	if h.start > h.cfg.ReplaySize {
+		h.replay = append([]HubEvent(nil), h.replay[h.start:]...)
+		h.start = 0
+	}
+}
+
+// Close marks the end of the run. Subscribers receive their queued events
+// and then stop.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for sub := range h.subs {
+		sub.end(nil)
+	}
+	clear(h.subs)
+}
+
+// LastEventID returns the ID of the most recent event
+func (h *Hub) LastEventID() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastSeq
+}
+
+// Subscribers returns the number of active subscriptions
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Subscribe joins the run at its current point. The subscription opens
+// with the run's RUN_STARTED, a MESSAGES_SNAPSHOT and, if the run has
+// state, a STATE_SNAPSHOT, all carrying the current event ID, followed by
+// the live events.
+func (h *Hub) Subscribe() *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := h.newSubscription()
+	if h.lastSeq > 0 {
+		for _, evt := range h.snap.events(true) {
+			sub.queue = append(sub.queue, HubEvent{ID: h.lastSeq, Event: evt})
+		}
+	}
+	h.attach(sub)
+	return sub
+}
+
+// SubscribeAfter replays the events after id and then follows the live
+// run. It fails with ErrReplayUnavailable if some of those events are no
+// longer buffered.
+func (h *Hub) SubscribeAfter(id int64) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	oldest := h.lastSeq - int64(len(h.replay)-h.start) + 1
+	if id < 0 || id > h.lastSeq || id+1 < oldest {
+		return nil, ErrReplayUnavailable
+	}
+
+	sub := h.newSubscription()
+	for _, e := range h.replay[h.start:] {
+		if e.ID > id {
+			sub.queue = append(sub.queue, e)
+		}
+	}
+	h.attach(sub)
+	return sub, nil
+}
+
+// ServeHTTP streams the run as SSE. A request with Last-Event-ID resumes
+// after that event; any other request joins with a snapshot.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sub, err := h.subscribeRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), subscribeErrorStatus(err))
+		return
+	}
+
+	serveSubscription(w, r, sub, h.cfg.KeepAlive)
+}
+
+// subscribeErrorStatus maps a subscribeRequest error to an HTTP status
+func subscribeErrorStatus(err error) int {
+	if errors.Is(err, ErrReplayUnavailable) {
+		return http.StatusGone
+	}
+	return http.StatusBadRequest
+}
+
+// subscribeRequest subscribes according to the request's Last-Event-ID
+func (h *Hub) subscribeRequest(r *http.Request) (*Subscription, error) {
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		return h.Subscribe(), nil
+	}
+
+	id, err := strconv.ParseInt(lastID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Last-Event-ID: %q", lastID)
+	}
+	return h.SubscribeAfter(id)
+}
+
+// newSubscription creates a subscription; h.mu must be held
+func (h *Hub) newSubscription() *Subscription {
+	limit := h.cfg.QueueSize
+	if h.cfg.Backpressure == BackpressureBuffer {
+		limit = h.cfg.BufferLimit
+	}
+	return &Subscription{
+		hub:    h,
+		limit:  limit,
+		policy: h.cfg.Backpressure,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// attach starts delivering live events to sub; h.mu must be held
+func (h *Hub) attach(sub *Subscription) {
+	if h.closed {
+		sub.end(nil)
+		return
+	}
+	h.subs[sub] = struct{}{}
+}
+
+// resync replaces a lagging subscriber's queue with a snapshot of the run,
+// after ending what the subscriber had open when it started dropping events
+func (h *Hub) resync(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	sub.queue = sub.queue[:0]
+	for _, evt := range append(sub.closing, h.snap.events(false)...) {
+		sub.queue = append(sub.queue, HubEvent{ID: h.lastSeq, Event: evt})
+	}
+	sub.lagged, sub.closing = false, nil
+}
+
+// Subscription receives the events of a Hub. Its lock is always taken
+// after the hub's.
+type Subscription struct {
+	hub    *Hub
+	limit  int
+	policy BackpressurePolicy
+	notify chan struct{}
+
+	mu     sync.Mutex
+	queue  []HubEvent
+	lagged bool
+	done   bool
+	err    error
+	// closing ends the messages and tool calls that were open when the
+	// subscriber started dropping events
+	closing []events.Event
+}
+
+// push queues an event for delivery, applying the backpressure policy.
+// It returns false once the subscription no longer takes events. h.mu
+// must be held.
+func (s *Subscription) push(e HubEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done {
+		return false
+	}
+	if s.lagged {
+		return true
+	}
+
+	if len(s.queue) >= s.limit {
+		switch s.policy {
+		case BackpressureDisconnect:
+			s.done, s.err = true, ErrSlowSubscriber
+			s.wake()
+			return false
+		case BackpressureDrop, BackpressureBuffer:
+			s.lagged = true
+			s.closing = s.hub.snap.reducer.closing()
+			return true
+		}
+	}
+
+	s.queue = append(s.queue, e)
+	s.wake()
+	return true
+}
+
+// end stops the subscription after its queued events; h.mu must be held
+func (s *Subscription) end(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.done {
+		s.done, s.err = true, err
+	}
+	s.wake()
+}
+
+// wake signals the reader without blocking; s.mu must be held
+func (s *Subscription) wake() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Events yields the subscription's events until the run ends, the
+// subscription is closed or ctx is done. Check Err afterwards to tell a
+// slow subscriber apart from the end of the run.
+func (s *Subscription) Events(ctx context.Context) iter.Seq[HubEvent] {
+	return func(yield func(HubEvent) bool) {
+		defer s.Close()
+
+		for {
+			s.mu.Lock()
+			batch := s.queue
+			s.queue = nil
+			lagged, done := s.lagged, s.done
+			s.mu.Unlock()
+
+			for _, e := range batch {
+				if !yield(e) {
+					return
+				}
+			}
+			if len(batch) > 0 {
+				continue
+			}
+
+			// Caught up after dropping events
+			if lagged {
+				s.hub.resync(s)
+				continue
+			}
+			if done {
+				return
+			}
+
+			select {
+			case <-s.notify:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Err reports why the subscription ended early, if it did
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close detaches the subscription from the hub
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	delete(s.hub.subs, s)
+	s.end(nil)
+}
+
+// serveSubscription writes a subscription's events as SSE until it ends
+// or the client goes away
+func serveSubscription(w http.ResponseWriter, r *http.Request, sub *Subscription, keepAlive SSEKeepAlive) {
+	defer sub.Close()
+
+	conn := startSSE(w, keepAlive)
+	defer conn.close()
+
+	for e := range sub.Events(r.Context()) {
+		if err := conn.write(func(w io.Writer) error {
+			return writeSSEEvent(w, strconv.FormatInt(e.ID, 10), e.Event)
+		}); err != nil {
+			return
+		}
+	}
+}
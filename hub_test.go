@@ -0,0 +1,318 @@
+package aguigo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectHub drains a subscription and returns its event IDs and types
+func collectHub(sub *Subscription) ([]int64, []string) {
+	var ids []int64
+	var types []string
+	for e := range sub.Events(context.Background()) {
+		ids = append(ids, e.ID)
+		types = append(types, string(e.Event.Type()))
+	}
+	return ids, types
+}
+
+func TestHub_SubscribeAfter(t *testing.T) {
+	t.Run("replays events after the given ID", func(t *testing.T) {
+		hub := NewHub(HubConfig{})
+		hub.Publish(events.NewCustomEvent("a"), events.NewCustomEvent("b"), events.NewCustomEvent("c"))
+		hub.Close()
+
+		for after, want := range map[int64][]int64{0: {1, 2, 3}, 2: {3}, 3: nil} {
+			sub, err := hub.SubscribeAfter(after)
+			require.NoError(t, err)
+			ids, _ := collectHub(sub)
+			assert.Equal(t, want, ids)
+		}
+	})
+
+	t.Run("keeps only the most recent events", func(t *testing.T) {
+		hub := NewHub(HubConfig{ReplaySize: 2})
+		for i := 0; i < 7; i++ {
+			hub.Publish(events.NewCustomEvent("tick"))
+		}
+		hub.Close()
+
+		sub, err := hub.SubscribeAfter(5)
+		require.NoError(t, err)
+		ids, _ := collectHub(sub)
+		assert.Equal(t, []int64{6, 7}, ids)
+
+		_, err = hub.SubscribeAfter(4)
+		assert.ErrorIs(t, err, ErrReplayUnavailable)
+		_, err = hub.SubscribeAfter(8)
+		assert.ErrorIs(t, err, ErrReplayUnavailable)
+	})
+
+	t.Run("follows the live run", func(t *testing.T) {
+		hub := NewHub(HubConfig{})
+		sub, err := hub.SubscribeAfter(0)
+		require.NoError(t, err)
+
+		go func() {
+			hub.Publish(events.NewCustomEvent("a"))
+			hub.Publish(events.NewCustomEvent("b"))
+			hub.Close()
+		}()
+
+		ids, _ := collectHub(sub)
+		assert.Equal(t, []int64{1, 2}, ids)
+	})
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	hub := NewHub(HubConfig{
+		Messages: []Message{{ID: "m1", Role: "user", Content: []ContentPart{{Type: "text", Text: "Hi"}}}},
+		State:    map[string]any{"step": 0, "city": "Paris"},
+	})
+	hub.Publish(
+		events.NewRunStartedEvent("thread-1", "run-1"),
+		events.NewStateDeltaEvent([]events.JSONPatchOperation{{Op: "replace", Path: "/step", Value: 1}}),
+		events.NewTextMessageStartEvent("m2", events.WithRole("assistant")),
+		events.NewTextMessageContentEvent("m2", "Hel"),
+	)
+
+	// A late subscriber catches up from a snapshot, joins the open message
+	// and then follows the run
+	sub := hub.Subscribe()
+	assert.Equal(t, 1, hub.Subscribers())
+	hub.Publish(
+		events.NewTextMessageContentEvent("m2", "lo"),
+		events.NewTextMessageEndEvent("m2"),
+		events.NewRunFinishedEvent("thread-1", "run-1"),
+	)
+	hub.Close()
+
+	var got []HubEvent
+	for e := range sub.Events(context.Background()) {
+		got = append(got, e)
+	}
+	require.Len(t, got, 8)
+	assert.Equal(t, events.EventTypeRunStarted, got[0].Event.Type())
+	assert.Equal(t, int64(4), got[0].ID)
+
+	snapshot, ok := got[1].Event.(*events.MessagesSnapshotEvent)
+	require.True(t, ok)
+	require.Len(t, snapshot.Messages, 1)
+	assert.Equal(t, "Hi", *snapshot.Messages[0].Content)
+
+	state, ok := got[2].Event.(*events.StateSnapshotEvent)
+	require.True(t, ok)
+	assert.Equal(t, map[string]any{"step": float64(1), "city": "Paris"}, state.Snapshot)
+
+	assert.Equal(t, "m2", got[3].Event.(*events.TextMessageStartEvent).MessageID)
+	assert.Equal(t, "Hel", got[4].Event.(*events.TextMessageContentEvent).Delta)
+
+	var evts []events.Event
+	for _, e := range got {
+		evts = append(evts, e.Event)
+	}
+	assert.NoError(t, ValidateEvents(evts))
+	assert.Equal(t, []int64{5, 6, 7}, []int64{got[5].ID, got[6].ID, got[7].ID})
+	assert.Equal(t, 0, hub.Subscribers())
+
+	t.Run("after the run ended", func(t *testing.T) {
+		_, types := collectHub(hub.Subscribe())
+		assert.Equal(t, []string{"RUN_STARTED", "MESSAGES_SNAPSHOT", "STATE_SNAPSHOT", "RUN_FINISHED"}, types)
+	})
+}
+
+func TestHub_LateJoinersGetValidStreams(t *testing.T) {
+	run := []events.Event{
+		events.NewRunStartedEvent("thread-1", "run-1"),
+		events.NewTextMessageStartEvent("m1", events.WithRole("assistant")),
+		events.NewTextMessageContentEvent("m1", "Let me "),
+		events.NewTextMessageContentEvent("m1", "check"),
+		events.NewTextMessageEndEvent("m1"),
+		events.NewToolCallStartEvent("call-1", "get_weather", events.WithParentMessageID("m1")),
+		events.NewToolCallArgsEvent("call-1", `{"city":`),
+		events.NewToolCallArgsEvent("call-1", `"Paris"}`),
+		events.NewToolCallEndEvent("call-1"),
+		events.NewToolCallStartEvent("call-2", "get_time"),
+		events.NewToolCallArgsEvent("call-2", `{}`),
+		events.NewToolCallEndEvent("call-2"),
+		events.NewThinkingStartEvent().WithTitle("Planning"),
+		events.NewThinkingTextMessageStartEvent(),
+		events.NewThinkingTextMessageContentEvent("Hmm"),
+		events.NewThinkingTextMessageEndEvent(),
+		events.NewThinkingEndEvent(),
+		events.NewRunFinishedEvent("thread-1", "run-1"),
+	}
+	require.NoError(t, ValidateEvents(run))
+
+	// Join before each event in turn, so every open block is joined
+	for at := 1; at < len(run); at++ {
+		hub := NewHub(HubConfig{})
+		hub.Publish(run[:at]...)
+		sub := hub.Subscribe()
+		hub.Publish(run[at:]...)
+		hub.Close()
+
+		var got []events.Event
+		for e := range sub.Events(context.Background()) {
+			got = append(got, e.Event)
+		}
+		assert.NoError(t, ValidateEvents(got), "joined before event %d", at)
+
+		conv, err := Reduce(got)
+		require.NoError(t, err, "joined before event %d", at)
+		want, _ := Reduce(run)
+		assert.Equal(t, want.Messages, conv.Messages, "joined before event %d", at)
+	}
+
+	t.Run("resync", func(t *testing.T) {
+		last := len(run) - 1
+		for at := 1; at < last; at++ {
+			// The queue fills up at event at and drops the rest of the run
+			hub := NewHub(HubConfig{QueueSize: at, Backpressure: BackpressureDrop})
+			sub, err := hub.SubscribeAfter(0)
+			require.NoError(t, err)
+			hub.Publish(run[:last]...)
+
+			got := make(chan []events.Event)
+			go func() {
+				var evts []events.Event
+				for e := range sub.Events(context.Background()) {
+					evts = append(evts, e.Event)
+				}
+				got <- evts
+			}()
+
+			// Finish the run once the subscriber has resynced mid-run
+			require.Eventually(t, func() bool {
+				sub.mu.Lock()
+				defer sub.mu.Unlock()
+				return !sub.lagged
+			}, time.Second, time.Millisecond)
+			hub.Publish(run[last])
+			hub.Close()
+
+			assert.NoError(t, ValidateEvents(<-got), "lagged at event %d", at)
+		}
+	})
+}
+
+func TestHub_Backpressure(t *testing.T) {
+	publish := func(hub *Hub, n int) {
+		for i := 0; i < n; i++ {
+			hub.Publish(events.NewCustomEvent("tick"))
+		}
+	}
+
+	t.Run("disconnect", func(t *testing.T) {
+		hub := NewHub(HubConfig{QueueSize: 2})
+		slow, err := hub.SubscribeAfter(0)
+		require.NoError(t, err)
+		fast, err := hub.SubscribeAfter(0)
+		require.NoError(t, err)
+
+		// The fast subscriber keeps up; the slow one never reads
+		next, stop := iterPull(fast)
+		defer stop()
+		for i := 0; i < 5; i++ {
+			hub.Publish(events.NewCustomEvent("tick"))
+			e, ok := next()
+			require.True(t, ok)
+			assert.Equal(t, int64(i+1), e.ID)
+		}
+
+		ids, _ := collectHub(slow)
+		assert.Equal(t, []int64{1, 2}, ids)
+		assert.ErrorIs(t, slow.Err(), ErrSlowSubscriber)
+		assert.NoError(t, fast.Err())
+		assert.Equal(t, 1, hub.Subscribers())
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		hub := NewHub(HubConfig{QueueSize: 2, Backpressure: BackpressureDrop})
+		hub.Publish(events.NewRunStartedEvent("thread-1", "run-1"))
+		sub, err := hub.SubscribeAfter(1)
+		require.NoError(t, err)
+
+		publish(hub, 5)
+		hub.Close()
+
+		// Two queued events, then a resync instead of the dropped ones
+		ids, types := collectHub(sub)
+		assert.Equal(t, []int64{2, 3, 6}, ids)
+		assert.Equal(t, []string{"CUSTOM", "CUSTOM", "MESSAGES_SNAPSHOT"}, types)
+		assert.NoError(t, sub.Err())
+	})
+
+	t.Run("buffer", func(t *testing.T) {
+		hub := NewHub(HubConfig{QueueSize: 2, Backpressure: BackpressureBuffer})
+		sub, err := hub.SubscribeAfter(0)
+		require.NoError(t, err)
+
+		publish(hub, 5)
+		hub.Close()
+
+		ids, _ := collectHub(sub)
+		assert.Equal(t, []int64{1, 2, 3, 4, 5}, ids)
+	})
+
+	t.Run("buffer falls back to drop at its limit", func(t *testing.T) {
+		hub := NewHub(HubConfig{QueueSize: 2, BufferLimit: 4, Backpressure: BackpressureBuffer})
+		sub, err := hub.SubscribeAfter(0)
+		require.NoError(t, err)
+
+		publish(hub, 8)
+		hub.Close()
+
+		ids, types := collectHub(sub)
+		assert.Equal(t, []int64{1, 2, 3, 4, 8}, ids)
+		assert.Equal(t, "MESSAGES_SNAPSHOT", types[len(types)-1])
+		assert.NoError(t, sub.Err())
+	})
+}
+
+// iterPull reads a subscription one event at a time
+func iterPull(sub *Subscription) (func() (HubEvent, bool), func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan HubEvent)
+	go func() {
+		defer close(ch)
+		for e := range sub.Events(ctx) {
+			ch <- e
+		}
+	}()
+	return func() (HubEvent, bool) {
+		e, ok := <-ch
+		return e, ok
+	}, cancel
+}
+
+func TestHub_ServeHTTP(t *testing.T) {
+	hub := NewHub(HubConfig{ReplaySize: 2})
+	hub.Publish(events.NewRunStartedEvent("thread-1", "run-1"), events.NewCustomEvent("a"), events.NewCustomEvent("b"))
+	hub.Close()
+
+	get := func(lastEventID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		rr := httptest.NewRecorder()
+		hub.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("")
+	assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+	assert.Equal(t, []string{"RUN_STARTED", "MESSAGES_SNAPSHOT"}, frameTypes(readSSEFrames(t, rr.Body, nil)))
+
+	assert.Equal(t, []sseFrame{{ID: "3", Type: "CUSTOM"}}, readSSEFrames(t, get("2").Body, nil))
+	assert.Equal(t, http.StatusGone, get("0").Code)
+	assert.Equal(t, http.StatusBadRequest, get("abc").Code)
+}
@@ -0,0 +1,128 @@
+package aguigo
+
+import (
+	"slices"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// EventInterceptor inspects every outgoing event before it is written.
+// Intercept returns the events to pass on: the event itself, a rewritten
+// event, nothing to drop it, or several events to inject extra ones.
+// Interceptors may block to delay an event; wait on ctx.Done() to stop
+// when the run is cancelled. Detached and background runs outlive their
+// request, so don't wait on ctx.Request.Context().
+type EventInterceptor interface {
+	Intercept(ctx HandlerContext, evt events.Event) []events.Event
+}
+
+// EventInterceptorFunc adapts a function to the EventInterceptor interface
+type EventInterceptorFunc func(ctx HandlerContext, evt events.Event) []events.Event
+
+// Intercept calls f(ctx, evt)
+func (f EventInterceptorFunc) Intercept(ctx HandlerContext, evt events.Event) []events.Event {
+	return f(ctx, evt)
+}
+
+// EventFlusher is implemented by interceptors that hold events back. Flush is
+// called once the run ends, right after the interceptor handles RUN_FINISHED
+// or RUN_ERROR, and returns any events still buffered; they are written
+// before that event. It is also called when the client goes away, so the
+// run's state can be released.
+type EventFlusher interface {
+	Flush(ctx HandlerContext) []events.Event
+}
+
+// ChainInterceptors combines interceptors into one; events flow through them in order
+func ChainInterceptors(interceptors ...EventInterceptor) EventInterceptor {
+	return interceptorChain(interceptors)
+}
+
+// FilterEvents returns an interceptor that drops events for which keep returns false
+func FilterEvents(keep func(ctx HandlerContext, evt events.Event) bool) EventInterceptor {
+	return EventInterceptorFunc(func(ctx HandlerContext, evt events.Event) []events.Event {
+		if !keep(ctx, evt) {
+			return nil
+		}
+		return []events.Event{evt}
+	})
+}
+
+// interceptorChain applies interceptors in order, feeding each one's output to the next
+type interceptorChain []EventInterceptor
+
+// Intercept implements EventInterceptor
+func (c interceptorChain) Intercept(ctx HandlerContext, evt events.Event) []events.Event {
+	return c.apply(ctx, 0, []events.Event{evt})
+}
+
+// Flush implements EventFlusher, flushing each interceptor and passing the
+// released events through the interceptors after it
+func (c interceptorChain) Flush(ctx HandlerContext) []events.Event {
+	var result []events.Event
+	for i, interceptor := range c {
+		f, ok := interceptor.(EventFlusher)
+		if !ok {
+			continue
+		}
+		result = append(result, c.apply(ctx, i+1, f.Flush(ctx))...)
+	}
+	return result
+}
+
+// finish passes the last events of a run through the chain and flushes every
+// interceptor. An interceptor is flushed right after it handles the terminal
+// event, and what it held back is put before that event, so the stream still
+// ends with RUN_FINISHED or RUN_ERROR.
+func (c interceptorChain) finish(ctx HandlerContext, evts []events.Event) []events.Event {
+	for _, interceptor := range c {
+		if inner, ok := interceptor.(interceptorChain); ok {
+			evts = inner.finish(ctx, evts)
+			continue
+		}
+
+		f, ok := interceptor.(EventFlusher)
+		flushed := !ok
+		var next []events.Event
+		for _, evt := range evts {
+			out := interceptor.Intercept(ctx, evt)
+			if !flushed && isTerminalEvent(evt) {
+				at := slices.IndexFunc(out, isTerminalEvent)
+				if at < 0 {
+					at = len(out)
+				}
+				out = slices.Concat(out[:at], f.Flush(ctx), out[at:])
+				flushed = true
+			}
+			next = append(next, out...)
+		}
+		if !flushed {
+			next = append(next, f.Flush(ctx)...)
+		}
+		evts = next
+	}
+	return evts
+}
+
+// isTerminalEvent reports whether evt ends a run
+func isTerminalEvent(evt events.Event) bool {
+	switch evt.Type() {
+	case events.EventTypeRunFinished, events.EventTypeRunError:
+		return true
+	}
+	return false
+}
+
+func (c interceptorChain) apply(ctx HandlerContext, from int, evts []events.Event) []events.Event {
+	for _, interceptor := range c[from:] {
+		if len(evts) == 0 {
+			return nil
+		}
+		var next []events.Event
+		for _, evt := range evts {
+			next = append(next, interceptor.Intercept(ctx, evt)...)
+		}
+		evts = next
+	}
+	return evts
+}
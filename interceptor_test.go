@@ -0,0 +1,283 @@
+package aguigo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// holdBackInterceptor buffers every event, or only events of type only,
+// until Flush
+type holdBackInterceptor struct {
+	only events.EventType
+	held []events.Event
+}
+
+func (h *holdBackInterceptor) Intercept(_ HandlerContext, evt events.Event) []events.Event {
+	if h.only != "" && evt.Type() != h.only {
+		return []events.Event{evt}
+	}
+	h.held = append(h.held, evt)
+	return nil
+}
+
+func (h *holdBackInterceptor) Flush(HandlerContext) []events.Event {
+	held := h.held
+	h.held = nil
+	return held
+}
+
+func TestChainInterceptors(t *testing.T) {
+	hctx := HandlerContext{ThreadID: "thread-1", RunID: "run-1", UserID: "user-1"}
+
+	t.Run("applies interceptors in order", func(t *testing.T) {
+		var seen []string
+		chain := ChainInterceptors(
+			EventInterceptorFunc(func(ctx HandlerContext, evt events.Event) []events.Event {
+				seen = append(seen, "first:"+string(evt.Type()))
+				// Inject a custom event after every event
+				return []events.Event{evt, events.NewCustomEvent("injected", events.WithValue(ctx.UserID))}
+			}),
+			EventInterceptorFunc(func(_ HandlerContext, evt events.Event) []events.Event {
+				seen = append(seen, "second:"+string(evt.Type()))
+				return []events.Event{evt}
+			}),
+		)
+
+		out := chain.Intercept(hctx, events.NewRunStartedEvent("thread-1", "run-1"))
+
+		require.Len(t, out, 2)
+		assert.Equal(t, events.EventTypeRunStarted, out[0].Type())
+		assert.Equal(t, "user-1", out[1].(*events.CustomEvent).Value)
+		assert.Equal(t, []string{"first:RUN_STARTED", "second:RUN_STARTED", "second:CUSTOM"}, seen)
+	})
+
+	t.Run("dropped events do not reach later interceptors", func(t *testing.T) {
+		called := false
+		chain := ChainInterceptors(
+			FilterEvents(func(_ HandlerContext, evt events.Event) bool { return false }),
+			EventInterceptorFunc(func(_ HandlerContext, evt events.Event) []events.Event {
+				called = true
+				return []events.Event{evt}
+			}),
+		)
+
+		assert.Empty(t, chain.Intercept(hctx, events.NewRunStartedEvent("thread-1", "run-1")))
+		assert.False(t, called)
+	})
+
+	t.Run("flush passes held events through later interceptors", func(t *testing.T) {
+		hold := &holdBackInterceptor{}
+		chain := ChainInterceptors(
+			hold,
+			EventInterceptorFunc(func(_ HandlerContext, evt events.Event) []events.Event {
+				return []events.Event{events.NewCustomEvent("wrapped:" + string(evt.Type()))}
+			}),
+		)
+
+		assert.Empty(t, chain.Intercept(hctx, events.NewRunStartedEvent("thread-1", "run-1")))
+
+		out := chain.(EventFlusher).Flush(hctx)
+		require.Len(t, out, 1)
+		assert.Equal(t, "wrapped:RUN_STARTED", out[0].(*events.CustomEvent).Name)
+	})
+
+	t.Run("finish releases held events before the terminal event", func(t *testing.T) {
+		chain := interceptorChain{
+			&holdBackInterceptor{only: events.EventTypeTextMessageEnd},
+			NewValidationInterceptor(ValidationStrict, nil),
+		}
+
+		out := chain.Intercept(hctx, events.NewRunStartedEvent("thread-1", "run-1"))
+		for evt := range textSeq("m1", "Hello") {
+			out = append(out, chain.Intercept(hctx, evt)...)
+		}
+		out = append(out, chain.finish(hctx, []events.Event{events.NewRunFinishedEvent("thread-1", "run-1")})...)
+
+		assert.Equal(t, []events.EventType{
+			events.EventTypeRunStarted,
+			events.EventTypeTextMessageStart,
+			events.EventTypeTextMessageContent,
+			events.EventTypeTextMessageEnd,
+			events.EventTypeRunFinished,
+		}, eventTypes(out))
+	})
+}
+
+func TestHandler_Interceptors(t *testing.T) {
+	source := &MockEventSource{
+		RunFunc: func(ctx HandlerContext, input RunAgentInput) <-chan events.Event {
+			ch := make(chan events.Event, 5)
+			ch <- events.NewRunStartedEvent(ctx.ThreadID, ctx.RunID)
+			ch <- events.NewThinkingStartEvent()
+			ch <- events.NewThinkingEndEvent()
+			ch <- events.NewCustomEvent("old_name")
+			ch <- events.NewRunFinishedEvent(ctx.ThreadID, ctx.RunID)
+			close(ch)
+			return ch
+		},
+	}
+
+	hideThinking := FilterEvents(func(ctx HandlerContext, evt events.Event) bool {
+		if ctx.Request.Header.Get("X-Hide-Thinking") == "" {
+			return true
+		}
+		return evt.Type() != events.EventTypeThinkingStart && evt.Type() != events.EventTypeThinkingEnd
+	})
+	rename := EventInterceptorFunc(func(_ HandlerContext, evt events.Event) []events.Event {
+		if custom, ok := evt.(*events.CustomEvent); ok && custom.Name == "old_name" {
+			return []events.Event{events.NewCustomEvent("new_name")}
+		}
+		return []events.Event{evt}
+	})
+
+	handler := New(Config{EventSource: source, Interceptors: []EventInterceptor{hideThinking, rename}})
+
+	t.Run("SSE", func(t *testing.T) {
+		body, _ := json.Marshal(RunAgentInput{ThreadID: "thread-1"})
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("X-Hide-Thinking", "1")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		out := rr.Body.String()
+		assert.Equal(t, 3, strings.Count(out, "data:"))
+		assert.NotContains(t, out, "THINKING")
+		assert.Contains(t, out, `"name":"new_name"`)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		body, _ := json.Marshal(RunAgentInput{ThreadID: "thread-1"})
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		evts := decodeJSONEvents(t, rr.Body.Bytes())
+		assert.Len(t, evts, 5)
+		assert.Equal(t, "new_name", evts[3]["name"])
+	})
+}
+
+func TestADKHandler_Interceptors(t *testing.T) {
+	var seenUser string
+	hold := &holdBackInterceptor{}
+	handler := newTestADKHandler(t,
+		[]model.LLMResponse{{Content: genai.NewContentFromText("Hello", genai.RoleModel)}},
+		WithInterceptors(
+			EventInterceptorFunc(func(ctx HandlerContext, evt events.Event) []events.Event {
+				seenUser = ctx.UserID
+				if evt.Type() == events.EventTypeTextMessageContent {
+					return nil
+				}
+				return []events.Event{evt}
+			}),
+			hold,
+		),
+		WithUserID(UserIDHeader("X-User-ID")),
+	)
+
+	body := `{"threadId":"thread-1","messages":[{"id":"m1","role":"user","content":"Hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("X-User-ID", "user-42")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	out := rr.Body.String()
+	assert.Equal(t, "user-42", seenUser)
+	assert.NotContains(t, out, "TEXT_MESSAGE_CONTENT")
+	// Held events are released at the end of the stream
+	assert.Contains(t, out, `"type":"RUN_STARTED"`)
+	assert.Contains(t, out, `"type":"RUN_FINISHED"`)
+}
+
+func TestInterceptors_FlushedBeforeTerminalEvent(t *testing.T) {
+	holdEnd := func() EventInterceptor {
+		return &holdBackInterceptor{only: events.EventTypeTextMessageEnd}
+	}
+
+	handlers := map[string]http.Handler{
+		"handler": New(Config{
+			EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+				return textSeq("m1", "Hello")
+			}),
+			Interceptors: []EventInterceptor{holdEnd()},
+		}),
+		"adk": newTestADKHandler(t, []model.LLMResponse{
+			{Content: genai.NewContentFromText("Hello", genai.RoleModel)},
+		}, WithInterceptors(holdEnd())),
+	}
+
+	for name, handler := range handlers {
+		t.Run(name, func(t *testing.T) {
+			types := jsonEventTypes(runJSON(t, handler, RunAgentInput{ThreadID: "thread-1", RunID: "run-1"}))
+			assert.Equal(t, []string{"RUN_STARTED", "TEXT_MESSAGE_START", "TEXT_MESSAGE_CONTENT", "TEXT_MESSAGE_END", "RUN_FINISHED"}, types)
+		})
+	}
+}
+
+func TestInterceptors_FlushedOnDisconnect(t *testing.T) {
+	validator := NewValidationInterceptor(ValidationStrict, nil).(*validationInterceptor)
+	redactor := NewPIIRedactor(RedactionConfig{})
+
+	// The client goes away while the redactor holds back message text
+	var disconnect context.CancelFunc
+	disconnecter := EventInterceptorFunc(func(ctx HandlerContext, evt events.Event) []events.Event {
+		if evt.Type() == events.EventTypeTextMessageContent && disconnect != nil {
+			disconnect()
+			disconnect = nil
+		}
+		return []events.Event{evt}
+	})
+	interceptors := []EventInterceptor{disconnecter, redactor, validator}
+
+	handlers := map[string]http.Handler{
+		"handler": New(Config{
+			EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+				return textSeq("m1", "reach me at jane@example.com")
+			}),
+			Interceptors: interceptors,
+		}),
+		"adk": newTestADKHandler(t, []model.LLMResponse{
+			{Content: genai.NewContentFromText("reach me at jane@example.com", genai.RoleModel)},
+		}, WithInterceptors(interceptors...)),
+	}
+
+	for name, handler := range handlers {
+		t.Run(name, func(t *testing.T) {
+			post := func(ctx context.Context) *httptest.ResponseRecorder {
+				body := `{"threadId":"thread-1","runId":"run-1","messages":[{"id":"u1","role":"user","content":"Hi"}]}`
+				req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)).WithContext(ctx)
+				req.Header.Set("Accept", "application/json")
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+				return rr
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			disconnect = cancel
+			post(ctx)
+
+			assert.Empty(t, validator.validators)
+			assert.Empty(t, redactor.runs)
+
+			// A retry with the same IDs is validated as a new run
+			out := post(context.Background()).Body.String()
+			assert.NotContains(t, out, SequenceErrorCode)
+			assert.Contains(t, out, `"type":"RUN_FINISHED"`)
+			assert.Contains(t, out, "[REDACTED_EMAIL]")
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package aguigo
+
+import (
+	"io"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// jsonArrayWriter streams events as the elements of a JSON array, so the
+// response needs no more memory than one event
+type jsonArrayWriter struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONArrayWriter(w io.Writer) *jsonArrayWriter {
+	return &jsonArrayWriter{w: w}
+}
+
+// write appends one event to the array, opening it on the first call
+func (a *jsonArrayWriter) write(evt events.Event) error {
+	data, err := evt.ToJSON()
+	if err != nil {
+		return err
+	}
+
+	sep := ","
+	if a.count == 0 {
+		sep = "["
+	}
+	if _, err := io.WriteString(a.w, sep); err != nil {
+		return err
+	}
+	if _, err := a.w.Write(data); err != nil {
+		return err
+	}
+	a.count++
+	return nil
+}
+
+// close ends the array; an array without events is written as []
+func (a *jsonArrayWriter) close() error {
+	end := "]\n"
+	if a.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(a.w, end)
+	return err
+}
+
+// writeNDJSONEvent writes one event as a line of JSON
+func writeNDJSONEvent(w io.Writer, evt events.Event) error {
+	data, err := evt.ToJSON()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,107 @@
+package aguigo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONArrayWriter(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, newJSONArrayWriter(&buf).close())
+		assert.Equal(t, "[]\n", buf.String())
+	})
+
+	t.Run("events", func(t *testing.T) {
+		var buf bytes.Buffer
+		array := newJSONArrayWriter(&buf)
+		require.NoError(t, array.write(events.NewCustomEvent("a")))
+		assert.True(t, strings.HasPrefix(buf.String(), `[{"type":"CUSTOM"`))
+
+		require.NoError(t, array.write(events.NewCustomEvent("b")))
+		require.NoError(t, array.close())
+
+		var got []map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+		require.Len(t, got, 2)
+		assert.Equal(t, "a", got[0]["name"])
+		assert.Equal(t, "b", got[1]["name"])
+	})
+}
+
+// flushRecorder records what has been flushed to the client so far
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed []string
+}
+
+func (r *flushRecorder) Flush() {
+	r.flushed = append(r.flushed, r.Body.String())
+}
+
+func TestHandler_JSONStreamsPerEvent(t *testing.T) {
+	for _, contentType := range []string{ContentTypeJSON, ContentTypeNDJSON} {
+		t.Run(contentType, func(t *testing.T) {
+			handler := New(Config{
+				EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+					return textSeq("m1", "Hello", " world")
+				}),
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"threadId":"thread-1","runId":"run-1"}`))
+			req.Header.Set("Accept", contentType)
+			rr := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, contentType, rr.Header().Get("Content-Type"))
+			// RUN_STARTED, three text events and RUN_FINISHED, each flushed on its own
+			require.Len(t, rr.flushed, 6)
+			assert.Contains(t, rr.flushed[0], "RUN_STARTED")
+			assert.NotContains(t, rr.flushed[0], "TEXT_MESSAGE_START")
+		})
+	}
+}
+
+func TestHandler_JSONStopsWhenClientGone(t *testing.T) {
+	for _, contentType := range []string{ContentTypeJSON, ContentTypeNDJSON} {
+		t.Run(contentType, func(t *testing.T) {
+			ctx, disconnect := context.WithCancel(context.Background())
+			var produced int
+			handler := New(Config{
+				EventSource: SeqFunc(func(hctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+					return func(yield func(events.Event, error) bool) {
+						for i := 0; i < 100; i++ {
+							produced++
+							if i == 2 {
+								disconnect()
+							}
+							if !yield(events.NewCustomEvent("tick"), nil) {
+								return
+							}
+						}
+					}
+				}),
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"threadId":"thread-1","runId":"run-1"}`)).WithContext(ctx)
+			req.Header.Set("Accept", contentType)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, 3, produced)
+			// The event produced after the disconnect is never written
+			assert.Equal(t, 2, strings.Count(rr.Body.String(), `"CUSTOM"`))
+			assert.NotContains(t, rr.Body.String(), "RUN_FINISHED")
+		})
+	}
+}
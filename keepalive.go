@@ -0,0 +1,124 @@
+package aguigo
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SSEKeepAlive keeps idle SSE connections open through proxies and load
+// balancers that close connections after a period without data
+type SSEKeepAlive struct {
+	// Heartbeat is how long a stream may stay idle before a comment line is
+	// written; zero disables heartbeats
+	Heartbeat time.Duration
+	// Retry is sent as the SSE retry field, telling clients how long to wait
+	// before reconnecting; zero leaves it to the client
+	Retry time.Duration
+}
+
+// sseConn writes SSE frames to a response. Heartbeats are written from a
+// separate goroutine, so all writes go through the same lock.
+type sseConn struct {
+	mu        sync.Mutex
+	w         http.ResponseWriter
+	err       error
+	lastWrite time.Time
+	stop      chan struct{}
+	stopped   chan struct{}
+}
+
+// startSSE writes the SSE headers and the retry field and starts the
+// heartbeat. Call close once the stream ends.
+func startSSE(w http.ResponseWriter, keepAlive SSEKeepAlive) *sseConn {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	c := &sseConn{
+		w:         w,
+		lastWrite: time.Now(),
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+
+	if keepAlive.Retry > 0 {
+		c.write(func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "retry: %d\n\n", keepAlive.Retry.Milliseconds())
+			return err
+		})
+	}
+
+	if keepAlive.Heartbeat > 0 {
+		go c.heartbeat(keepAlive.Heartbeat)
+	} else {
+		close(c.stopped)
+	}
+	return c
+}
+
+// write runs fn under the connection lock and flushes. Once a write has
+// failed, every later write returns that error.
+func (c *sseConn) write(fn func(io.Writer) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+	if c.err = fn(c.w); c.err != nil {
+		return c.err
+	}
+	if f, ok := c.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	c.lastWrite = time.Now()
+	return nil
+}
+
+// heartbeat writes a comment whenever the stream has been idle for interval
+func (c *sseConn) heartbeat(interval time.Duration) {
+	defer close(c.stopped)
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-timer.C:
+		}
+
+		c.mu.Lock()
+		idle := time.Since(c.lastWrite)
+		c.mu.Unlock()
+
+		if idle < interval {
+			timer.Reset(interval - idle)
+			continue
+		}
+		if err := c.write(func(w io.Writer) error {
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err
+		}); err != nil {
+			return
+		}
+		timer.Reset(interval)
+	}
+}
+
+// close stops the heartbeat and waits for it, so nothing is written to the
+// response after the handler returns
+func (c *sseConn) close() {
+	select {
+	case <-c.stop:
+	default:
+		close(c.stop)
+	}
+	<-c.stopped
+}
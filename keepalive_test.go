@@ -0,0 +1,79 @@
+package aguigo
+
+import (
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestSSEConn(t *testing.T) {
+	t.Run("writes heartbeats while idle", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		conn := startSSE(rr, SSEKeepAlive{Heartbeat: 5 * time.Millisecond, Retry: 2 * time.Second})
+		time.Sleep(30 * time.Millisecond)
+		conn.close()
+
+		body := rr.Body.String()
+		assert.True(t, strings.HasPrefix(body, "retry: 2000\n\n"), body)
+		assert.Contains(t, body, ": ping\n\n")
+		assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+	})
+
+	t.Run("no heartbeat by default", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		conn := startSSE(rr, SSEKeepAlive{})
+		time.Sleep(10 * time.Millisecond)
+		conn.close()
+
+		assert.Empty(t, rr.Body.String())
+	})
+}
+
+func TestHandler_SSEKeepAlive(t *testing.T) {
+	handler := New(Config{
+		KeepAlive: SSEKeepAlive{Heartbeat: 5 * time.Millisecond, Retry: time.Second},
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return func(yield func(events.Event, error) bool) {
+				// A long tool call: nothing to send for a while
+				time.Sleep(30 * time.Millisecond)
+				yield(events.NewCustomEvent("done"), nil)
+			}
+		}),
+	})
+
+	body := `{"threadId":"thread-1","runId":"run-1"}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Accept", "text/event-stream")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	raw := rr.Body.String()
+	assert.True(t, strings.HasPrefix(raw, "retry: 1000\n\n"), raw)
+	assert.Contains(t, raw, ": ping\n\n")
+	assert.Equal(t, []string{"RUN_STARTED", "CUSTOM", "RUN_FINISHED"}, frameTypes(readSSEFrames(t, strings.NewReader(raw), nil)))
+}
+
+func TestADKHandler_SSEKeepAlive(t *testing.T) {
+	handler := newTestADKHandler(t, []model.LLMResponse{
+		{Content: genai.NewContentFromText("Hello", genai.RoleModel)},
+	}, WithSSEKeepAlive(time.Minute, 500*time.Millisecond))
+
+	body := `{"threadId":"thread-1","runId":"run-1","messages":[{"id":"m1","role":"user","content":"Hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	raw := rr.Body.String()
+	require.True(t, strings.HasPrefix(raw, "retry: 500\n\n"), raw)
+	assert.NotContains(t, raw, ": ping")
+	assert.Contains(t, frameTypes(readSSEFrames(t, strings.NewReader(raw), nil)), "TEXT_MESSAGE_CONTENT")
+}
@@ -0,0 +1,98 @@
+package aguigo
+
+import (
+	"fmt"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// StreamFunc is an EventSource written as a plain function. It produces the
+// run's content by calling emit; the handler adds RUN_STARTED and
+// RUN_FINISHED around it. A returned error or a panic ends the run with RUN_ERROR.
+// Once the run is cancelled emit drops events; f should watch ctx.Done() to
+// stop its work early.
+type StreamFunc func(ctx HandlerContext, input RunAgentInput, emit func(events.Event)) error
+
+// Run implements EventSource
+func (f StreamFunc) Run(ctx HandlerContext, input RunAgentInput) <-chan events.Event {
+	ch := make(chan events.Event)
+
+	go func() {
+		defer close(ch)
+		defer func() {
+			if r := recover(); r != nil {
+				sendEvent(ctx, ch, events.NewRunErrorEvent(fmt.Sprintf("event source panicked: %v", r), events.WithRunID(ctx.RunID)))
+			}
+		}()
+
+		if err := f(ctx, input, func(evt events.Event) { sendEvent(ctx, ch, evt) }); err != nil {
+			sendEvent(ctx, ch, events.NewRunErrorEvent(err.Error(), events.WithRunID(ctx.RunID)))
+		}
+	}()
+
+	return ch
+}
+
+// runLifecycle wraps an event source's stream with RUN_STARTED and a
+// terminal RUN_FINISHED or RUN_ERROR. Lifecycle events sent by the source
+// itself are reconciled, so sources written either way produce one of each.
+// Unmanaged runs pass the source's events through, but still end with
+// RUN_ERROR when the source fails before sending a terminal event.
+type runLifecycle struct {
+	hctx     HandlerContext
+	managed  bool
+	finished bool
+}
+
+func newRunLifecycle(hctx HandlerContext, managed bool) *runLifecycle {
+	return &runLifecycle{hctx: hctx, managed: managed}
+}
+
+// start returns the RUN_STARTED event
+func (l *runLifecycle) start() []events.Event {
+	if !l.managed {
+		return nil
+	}
+	return []events.Event{events.NewRunStartedEvent(l.hctx.ThreadID, l.hctx.RunID)}
+}
+
+// process passes a source event through, dropping the source's own
+// RUN_STARTED and anything it sends after ending the run
+func (l *runLifecycle) process(evt events.Event) []events.Event {
+	if !l.managed {
+		if isTerminalEvent(evt) {
+			l.finished = true
+		}
+		return []events.Event{evt}
+	}
+	if l.finished {
+		return nil
+	}
+
+	switch evt.Type() {
+	case events.EventTypeRunStarted:
+		return nil
+	case events.EventTypeRunFinished, events.EventTypeRunError:
+		l.finished = true
+	}
+	return []events.Event{evt}
+}
+
+// fail returns a RUN_ERROR for err unless the run already ended, also when
+// the lifecycle is not managed
+func (l *runLifecycle) fail(err error) []events.Event {
+	if l.finished {
+		return nil
+	}
+	l.finished = true
+	return []events.Event{events.NewRunErrorEvent(err.Error(), events.WithRunID(l.hctx.RunID))}
+}
+
+// finish returns RUN_FINISHED unless the source already ended the run
+func (l *runLifecycle) finish() []events.Event {
+	if !l.managed || l.finished {
+		return nil
+	}
+	l.finished = true
+	return []events.Event{events.NewRunFinishedEvent(l.hctx.ThreadID, l.hctx.RunID)}
+}
@@ -0,0 +1,149 @@
+package aguigo
+
+import (
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_Lifecycle(t *testing.T) {
+	input := RunAgentInput{ThreadID: "thread-1", RunID: "run-1"}
+
+	t.Run("wraps content-only sources", func(t *testing.T) {
+		handler := New(Config{EventSource: StreamFunc(func(ctx HandlerContext, input RunAgentInput, emit func(events.Event)) error {
+			emit(events.NewTextMessageStartEvent("m1", events.WithRole("assistant")))
+			emit(events.NewTextMessageContentEvent("m1", "hi"))
+			emit(events.NewTextMessageEndEvent("m1"))
+			return nil
+		})})
+
+		evts := runJSON(t, handler, input)
+
+		assert.Equal(t, []string{"RUN_STARTED", "TEXT_MESSAGE_START", "TEXT_MESSAGE_CONTENT", "TEXT_MESSAGE_END", "RUN_FINISHED"}, jsonEventTypes(evts))
+		assert.Equal(t, "thread-1", evts[0]["threadId"])
+		assert.Equal(t, "run-1", evts[0]["runId"])
+	})
+
+	t.Run("does not duplicate lifecycle events sent by the source", func(t *testing.T) {
+		handler := New(Config{EventSource: &MockEventSource{
+			RunFunc: func(ctx HandlerContext, input RunAgentInput) <-chan events.Event {
+				ch := make(chan events.Event, 2)
+				ch <- events.NewRunStartedEvent(ctx.ThreadID, ctx.RunID)
+				ch <- events.NewRunFinishedEvent(ctx.ThreadID, ctx.RunID)
+				close(ch)
+				return ch
+			},
+		}})
+
+		assert.Equal(t, []string{"RUN_STARTED", "RUN_FINISHED"}, jsonEventTypes(runJSON(t, handler, input)))
+	})
+
+	t.Run("drops events after the source ends the run", func(t *testing.T) {
+		handler := New(Config{EventSource: StreamFunc(func(ctx HandlerContext, input RunAgentInput, emit func(events.Event)) error {
+			emit(events.NewRunFinishedEvent(ctx.ThreadID, ctx.RunID))
+			emit(events.NewCustomEvent("late"))
+			return errors.New("too late")
+		})})
+
+		evts := runJSON(t, handler, input)
+
+		assert.Equal(t, []string{"RUN_STARTED", "RUN_FINISHED"}, jsonEventTypes(evts))
+	})
+
+	t.Run("returned error becomes RUN_ERROR", func(t *testing.T) {
+		handler := New(Config{EventSource: StreamFunc(func(ctx HandlerContext, input RunAgentInput, emit func(events.Event)) error {
+			return errors.New("model unavailable")
+		})})
+
+		evts := runJSON(t, handler, input)
+
+		assert.Equal(t, []string{"RUN_STARTED", "RUN_ERROR"}, jsonEventTypes(evts))
+		assert.Equal(t, "model unavailable", evts[1]["message"])
+	})
+
+	t.Run("panicking goroutine becomes RUN_ERROR", func(t *testing.T) {
+		handler := New(Config{EventSource: StreamFunc(func(ctx HandlerContext, input RunAgentInput, emit func(events.Event)) error {
+			panic("boom")
+		})})
+
+		evts := runJSON(t, handler, input)
+
+		assert.Equal(t, []string{"RUN_STARTED", "RUN_ERROR"}, jsonEventTypes(evts))
+		assert.Contains(t, evts[1]["message"], "boom")
+	})
+
+	t.Run("panicking Run becomes RUN_ERROR", func(t *testing.T) {
+		handler := New(Config{EventSource: &MockEventSource{
+			RunFunc: func(ctx HandlerContext, input RunAgentInput) <-chan events.Event {
+				panic("cannot start")
+			},
+		}})
+
+		assert.Equal(t, []string{"RUN_STARTED", "RUN_ERROR"}, jsonEventTypes(runJSON(t, handler, input)))
+	})
+
+	t.Run("manual lifecycle passes events through", func(t *testing.T) {
+		handler := New(Config{
+			ManualLifecycle: true,
+			EventSource: StreamFunc(func(ctx HandlerContext, input RunAgentInput, emit func(events.Event)) error {
+				emit(events.NewCustomEvent("only"))
+				return nil
+			}),
+		})
+
+		assert.Equal(t, []string{"CUSTOM"}, jsonEventTypes(runJSON(t, handler, input)))
+	})
+
+	t.Run("manual lifecycle still reports failures", func(t *testing.T) {
+		tests := map[string]EventSource{
+			"error": SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+				return func(yield func(events.Event, error) bool) {
+					if yield(events.NewRunStartedEvent(ctx.ThreadID, ctx.RunID), nil) {
+						yield(nil, errors.New("boom"))
+					}
+				}
+			}),
+			"panic": SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+				return func(yield func(events.Event, error) bool) {
+					if yield(events.NewRunStartedEvent(ctx.ThreadID, ctx.RunID), nil) {
+						panic("boom")
+					}
+				}
+			}),
+			"timeout": SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+				return func(yield func(events.Event, error) bool) {
+					if yield(events.NewRunStartedEvent(ctx.ThreadID, ctx.RunID), nil) {
+						<-ctx.Done()
+					}
+				}
+			}),
+		}
+
+		for name, source := range tests {
+			t.Run(name, func(t *testing.T) {
+				handler := New(Config{ManualLifecycle: true, RunTimeout: 20 * time.Millisecond, EventSource: source})
+				assert.Equal(t, []string{"RUN_STARTED", "RUN_ERROR"}, jsonEventTypes(runJSON(t, handler, input)))
+			})
+		}
+	})
+
+	t.Run("manual lifecycle adds nothing after the source's terminal event", func(t *testing.T) {
+		handler := New(Config{
+			ManualLifecycle: true,
+			EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+				return func(yield func(events.Event, error) bool) {
+					if yield(events.NewRunStartedEvent(ctx.ThreadID, ctx.RunID), nil) &&
+						yield(events.NewRunFinishedEvent(ctx.ThreadID, ctx.RunID), nil) {
+						yield(nil, errors.New("late failure"))
+					}
+				}
+			}),
+		})
+
+		assert.Equal(t, []string{"RUN_STARTED", "RUN_FINISHED"}, jsonEventTypes(runJSON(t, handler, input)))
+	})
+}
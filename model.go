@@ -0,0 +1,267 @@
+package aguigo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"iter"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// ModelSource is an event source that streams a genai model directly, without
+// an ADK agent or session. Each run sends the whole message history of the
+// input, with its tool calls and results, and the input's tools as function
+// declarations. The model's response goes through the same part conversion as
+// ADKConverter, so registries and converter options apply.
+//
+// Tool calls are not executed: the run ends after the model asks for them and
+// the client sends the results back in a new run.
+//
+// The source sends no RUN_STARTED or RUN_FINISHED; it relies on the
+// handler's managed lifecycle and is not for use with ManualLifecycle.
+type ModelSource struct {
+	client *genai.Client
+	model  string
+	config *genai.GenerateContentConfig
+	opts   []Option
+}
+
+// NewModelSource creates a source that streams the named model through client
+func NewModelSource(client *genai.Client, model string) *ModelSource {
+	return &ModelSource{client: client, model: model}
+}
+
+// WithConfig sets the generation config sent with every run, such as the
+// system instruction, temperature or thinking config. Input tools and system
+// messages are added to a copy of it.
+func (s *ModelSource) WithConfig(config *genai.GenerateContentConfig) *ModelSource {
+	s.config = config
+	return s
+}
+
+// WithConverterOptions sets the options of the converter that turns the
+// model's response into events, such as WithConverterRegistry or WithRawEvents
+func (s *ModelSource) WithConverterOptions(opts ...Option) *ModelSource {
+	s.opts = append(s.opts, opts...)
+	return s
+}
+
+// Run implements EventSource
+func (s *ModelSource) Run(ctx HandlerContext, input RunAgentInput) <-chan events.Event {
+	return SeqToChan(ctx, s.Events(ctx, input))
+}
+
+// Events implements IterEventSource
+func (s *ModelSource) Events(hctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+	return func(yield func(events.Event, error) bool) {
+		ctx := hctx.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		contents, system, err := convertMessagesToGenAIContents(input.Messages)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		config := s.requestConfig(system, input.Tools)
+
+		conv := NewADKConverter(hctx.ThreadID, hctx.RunID, s.opts...)
+		emit := func(evts []events.Event) bool {
+			for _, evt := range evts {
+				if !yield(evt, nil) {
+					return false
+				}
+			}
+			return true
+		}
+
+		// Each chunk repeats the usage so far; only the last one is reported
+		var usage *genai.GenerateContentResponseUsageMetadata
+		for resp, err := range s.client.Models.GenerateContentStream(ctx, s.model, contents, config) {
+			// The handler reports its own cancellation
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				emit(conv.ErrorRun(err))
+				return
+			}
+
+			if resp.UsageMetadata != nil {
+				usage = resp.UsageMetadata
+			}
+			if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+				continue
+			}
+			adkEvent := &session.Event{
+				Author: s.model,
+				LLMResponse: model.LLMResponse{
+					Content: resp.Candidates[0].Content,
+					Partial: true,
+				},
+			}
+			if !emit(conv.ConvertEvent(adkEvent)) {
+				return
+			}
+		}
+
+		if !emit(conv.EndThinking()) || !emit(conv.EndTextMessage()) {
+			return
+		}
+		if usage != nil {
+			emit(conv.handleUsage(usage))
+		}
+	}
+}
+
+// requestConfig returns the config of one run: the source's config with the
+// run's system messages and tools added
+func (s *ModelSource) requestConfig(system []*genai.Part, tools []Tool) *genai.GenerateContentConfig {
+	config := &genai.GenerateContentConfig{}
+	if s.config != nil {
+		copied := *s.config
+		config = &copied
+	}
+
+	if len(system) > 0 {
+		var parts []*genai.Part
+		if config.SystemInstruction != nil {
+			parts = append(parts, config.SystemInstruction.Parts...)
+		}
+		config.SystemInstruction = genai.NewContentFromParts(append(parts, system...), genai.RoleUser)
+	}
+
+	if len(tools) > 0 {
+		decls := make([]*genai.FunctionDeclaration, 0, len(tools))
+		for _, tool := range tools {
+			decls = append(decls, &genai.FunctionDeclaration{
+				Name:                 tool.Name,
+				Description:          tool.Description,
+				ParametersJsonSchema: tool.Parameters,
+			})
+		}
+		config.Tools = append(append([]*genai.Tool(nil), config.Tools...), &genai.Tool{FunctionDeclarations: decls})
+	}
+
+	return config
+}
+
+// convertMessagesToGenAIContents converts the AG-UI message history to genai
+// contents. System and developer messages are returned separately as system
+// instruction parts. Consecutive messages with the same genai role are merged,
+// so the results of parallel tool calls are sent together.
+func convertMessagesToGenAIContents(messages []Message) ([]*genai.Content, []*genai.Part, error) {
+	var contents []*genai.Content
+	var system []*genai.Part
+	toolNames := make(map[string]string)
+
+	add := func(role genai.Role, parts []*genai.Part) {
+		if len(parts) == 0 {
+			return
+		}
+		if n := len(contents); n > 0 && contents[n-1].Role == string(role) {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			return
+		}
+		contents = append(contents, genai.NewContentFromParts(parts, role))
+	}
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system", "developer":
+			parts, err := convertContentParts(msg.Content)
+			if err != nil {
+				return nil, nil, fmt.Errorf("message %q: %w", msg.ID, err)
+			}
+			system = append(system, parts...)
+
+		case "user":
+			parts, err := convertContentParts(msg.Content)
+			if err != nil {
+				return nil, nil, fmt.Errorf("message %q: %w", msg.ID, err)
+			}
+			add(genai.RoleUser, parts)
+
+		case "assistant":
+			parts, err := convertContentParts(msg.Content)
+			if err != nil {
+				return nil, nil, fmt.Errorf("message %q: %w", msg.ID, err)
+			}
+			for _, call := range msg.ToolCalls {
+				var args map[string]any
+				if call.Function.Arguments != "" {
+					if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+						return nil, nil, fmt.Errorf("message %q: tool call %q has invalid arguments: %w", msg.ID, call.ID, err)
+					}
+				}
+				toolNames[call.ID] = call.Function.Name
+				parts = append(parts, &genai.Part{FunctionCall: &genai.FunctionCall{
+					ID:   call.ID,
+					Name: call.Function.Name,
+					Args: args,
+				}})
+			}
+			add(genai.RoleModel, parts)
+
+		case "tool":
+			name := toolNames[msg.ToolCallID]
+			if name == "" {
+				name = msg.Name
+			}
+			add(genai.RoleUser, []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+				ID:       msg.ToolCallID,
+				Name:     name,
+				Response: toolResponse(msg.Content),
+			}}})
+		}
+	}
+
+	return contents, system, nil
+}
+
+// convertContentParts converts AG-UI content parts to genai parts. Text
+// parts become text; other parts become inline data from their base64 data
+// or a file reference to their URL.
+func convertContentParts(content []ContentPart) ([]*genai.Part, error) {
+	var parts []*genai.Part
+	for _, c := range content {
+		switch {
+		case c.Type == "text":
+			if c.Text != "" {
+				parts = append(parts, &genai.Part{Text: c.Text})
+			}
+		case c.Data != "":
+			data, err := base64.StdEncoding.DecodeString(c.Data)
+			if err != nil {
+				return nil, fmt.Errorf("%s part has invalid base64 data: %w", c.Type, err)
+			}
+			parts = append(parts, &genai.Part{InlineData: &genai.Blob{MIMEType: c.MimeType, Data: data}})
+		case c.URL != "":
+			parts = append(parts, &genai.Part{FileData: &genai.FileData{MIMEType: c.MimeType, FileURI: c.URL}})
+		default:
+			return nil, fmt.Errorf("%s part has no data or url", c.Type)
+		}
+	}
+	return parts, nil
+}
+
+// toolResponse turns the content of a tool message into a function response.
+// A JSON object is sent as is; anything else is sent as the "output" field.
+func toolResponse(content []ContentPart) map[string]any {
+	var text string
+	for _, c := range content {
+		text += c.Text
+	}
+
+	var response map[string]any
+	if err := json.Unmarshal([]byte(text), &response); err == nil && response != nil {
+		return response
+	}
+	return map[string]any{"output": text}
+}
@@ -0,0 +1,241 @@
+package aguigo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+// newFakeModel serves chunks as a genai streaming response and records the
+// request body
+func newFakeModel(t *testing.T, chunks ...string) (*genai.Client, *map[string]any) {
+	t.Helper()
+
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Path, "models/gemini-test:streamGenerateContent")
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			io.WriteString(w, "data: "+chunk+"\n\n")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test-key",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: srv.URL},
+	})
+	require.NoError(t, err)
+	return client, &body
+}
+
+func postResult(t *testing.T, handler http.Handler, body string) RunResult {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Accept", ContentTypeResult)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var res RunResult
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	return res
+}
+
+func TestModelSource_Text(t *testing.T) {
+	client, body := newFakeModel(t,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello"}]}}],"usageMetadata":{"promptTokenCount":7,"totalTokenCount":7}}`,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":", world"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":3,"totalTokenCount":10}}`,
+	)
+	source := NewModelSource(client, "gemini-test").WithConfig(&genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText("Be brief.", genai.RoleUser),
+	})
+
+	res := postResult(t, New(Config{EventSource: source}), `{
+		"threadId": "thread-1",
+		"runId": "run-1",
+		"messages": [
+			{"id": "s1", "role": "system", "content": "Answer in English."},
+			{"id": "u1", "role": "user", "content": "Hi"}
+		],
+		"tools": [{"name": "search", "description": "Search the web", "parameters": {"type": "object"}}]
+	}`)
+
+	assert.Equal(t, RunStateFinished, res.Status)
+	require.Len(t, res.Messages, 1)
+	assert.Equal(t, "assistant", res.Messages[0].Role)
+	assert.Equal(t, "Hello, world", *res.Messages[0].Content)
+	assert.Equal(t, &Usage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}, res.Usage)
+
+	assert.Equal(t, []any{
+		map[string]any{"role": "user", "parts": []any{map[string]any{"text": "Hi"}}},
+	}, (*body)["contents"])
+	assert.Equal(t, map[string]any{
+		"role":  "user",
+		"parts": []any{map[string]any{"text": "Be brief."}, map[string]any{"text": "Answer in English."}},
+	}, (*body)["systemInstruction"])
+	assert.Equal(t, []any{map[string]any{"functionDeclarations": []any{map[string]any{
+		"name":                 "search",
+		"description":          "Search the web",
+		"parametersJsonSchema": map[string]any{"type": "object"},
+	}}}}, (*body)["tools"])
+}
+
+func TestModelSource_ToolCall(t *testing.T) {
+	client, _ := newFakeModel(t,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":"Let me check."}]}}]}`,
+		`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"id":"call-1","name":"search","args":{"q":"go"}}}]},"finishReason":"STOP"}]}`,
+	)
+	source := NewModelSource(client, "gemini-test")
+
+	evts := collectEvents(t, source.Events(HandlerContext{ThreadID: "t", RunID: "r", Context: context.Background()}, RunAgentInput{
+		Messages: []Message{{ID: "u1", Role: "user", Content: []ContentPart{{Type: "text", Text: "Find Go"}}}},
+	}))
+
+	assert.Equal(t, []events.EventType{
+		events.EventTypeTextMessageStart,
+		events.EventTypeTextMessageContent,
+		events.EventTypeTextMessageEnd,
+		events.EventTypeToolCallStart,
+		events.EventTypeToolCallArgs,
+		events.EventTypeToolCallEnd,
+	}, eventTypes(evts))
+
+	conv, err := Reduce(evts)
+	require.NoError(t, err)
+	calls := conv.PendingToolCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "call-1", calls[0].ID)
+	assert.Equal(t, `{"q":"go"}`, calls[0].Function.Arguments)
+}
+
+func TestModelSource_Thinking(t *testing.T) {
+	client, _ := newFakeModel(t,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":"Weighing ","thought":true}]}}]}`,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":"the options","thought":true}]}}]}`,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":"Go."}]},"finishReason":"STOP"}]}`,
+	)
+	source := NewModelSource(client, "gemini-test")
+
+	evts := collectEvents(t, source.Events(HandlerContext{ThreadID: "t", RunID: "r", Context: context.Background()}, RunAgentInput{
+		Messages: []Message{{ID: "u1", Role: "user", Content: []ContentPart{{Type: "text", Text: "Pick one"}}}},
+	}))
+
+	// The thought chunks share one thinking block
+	assert.Equal(t, []events.EventType{
+		events.EventTypeThinkingStart,
+		events.EventTypeThinkingTextMessageStart,
+		events.EventTypeThinkingTextMessageContent,
+		events.EventTypeThinkingTextMessageContent,
+		events.EventTypeThinkingTextMessageEnd,
+		events.EventTypeThinkingEnd,
+		events.EventTypeTextMessageStart,
+		events.EventTypeTextMessageContent,
+		events.EventTypeTextMessageEnd,
+	}, eventTypes(evts))
+
+	conv, err := Reduce(evts)
+	require.NoError(t, err)
+	require.Len(t, conv.Thinking, 1)
+	assert.Equal(t, "Weighing the options", conv.Thinking[0].Content)
+
+	t.Run("closed at the end of the run", func(t *testing.T) {
+		client, _ := newFakeModel(t,
+			`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hmm","thought":true}]},"finishReason":"STOP"}]}`,
+		)
+		res := postResult(t, New(Config{
+			EventSource:  NewModelSource(client, "gemini-test"),
+			Interceptors: []EventInterceptor{NewValidationInterceptor(ValidationStrict, nil)},
+		}), `{"threadId":"thread-1","messages":[{"id":"u1","role":"user","content":"Hi"}]}`)
+		assert.Equal(t, RunStateFinished, res.Status, res.Error)
+	})
+}
+
+func TestModelSource_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, `{"error":{"code":429,"message":"quota exceeded","status":"RESOURCE_EXHAUSTED"}}`)
+	}))
+	defer srv.Close()
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test-key",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: srv.URL},
+	})
+	require.NoError(t, err)
+
+	res := postResult(t, New(Config{EventSource: NewModelSource(client, "gemini-test")}),
+		`{"threadId":"thread-1","messages":[{"id":"u1","role":"user","content":"Hi"}]}`)
+	assert.Equal(t, RunStateFailed, res.Status)
+	assert.Contains(t, res.Error, "quota exceeded")
+}
+
+func TestConvertMessagesToGenAIContents(t *testing.T) {
+	t.Run("history", func(t *testing.T) {
+		contents, system, err := convertMessagesToGenAIContents([]Message{
+			{ID: "s1", Role: "developer", Content: []ContentPart{{Type: "text", Text: "Be brief."}}},
+			{ID: "u1", Role: "user", Content: []ContentPart{
+				{Type: "text", Text: "What is in these?"},
+				{Type: "image", MimeType: "image/png", Data: "aGVsbG8="},
+				{Type: "document", MimeType: "application/pdf", URL: "gs://bucket/doc.pdf"},
+			}},
+			{ID: "a1", Role: "assistant", ToolCalls: []events.ToolCall{
+				{ID: "call-1", Type: "function", Function: events.Function{Name: "ocr", Arguments: `{"page":1}`}},
+				{ID: "call-2", Type: "function", Function: events.Function{Name: "ocr", Arguments: `{"page":2}`}},
+			}},
+			ToolResultMessage("call-1", `{"text":"cat"}`),
+			ToolResultMessage("call-2", "dog"),
+			{ID: "a2", Role: "assistant", Content: []ContentPart{{Type: "text", Text: "A cat and a dog."}}},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []*genai.Part{{Text: "Be brief."}}, system)
+		require.Len(t, contents, 4)
+
+		assert.Equal(t, genai.NewContentFromParts([]*genai.Part{
+			{Text: "What is in these?"},
+			{InlineData: &genai.Blob{MIMEType: "image/png", Data: []byte("hello")}},
+			{FileData: &genai.FileData{MIMEType: "application/pdf", FileURI: "gs://bucket/doc.pdf"}},
+		}, genai.RoleUser), contents[0])
+
+		assert.Equal(t, genai.NewContentFromParts([]*genai.Part{
+			{FunctionCall: &genai.FunctionCall{ID: "call-1", Name: "ocr", Args: map[string]any{"page": float64(1)}}},
+			{FunctionCall: &genai.FunctionCall{ID: "call-2", Name: "ocr", Args: map[string]any{"page": float64(2)}}},
+		}, genai.RoleModel), contents[1])
+
+		// Results of parallel calls are sent in one content
+		assert.Equal(t, genai.NewContentFromParts([]*genai.Part{
+			{FunctionResponse: &genai.FunctionResponse{ID: "call-1", Name: "ocr", Response: map[string]any{"text": "cat"}}},
+			{FunctionResponse: &genai.FunctionResponse{ID: "call-2", Name: "ocr", Response: map[string]any{"output": "dog"}}},
+		}, genai.RoleUser), contents[2])
+
+		assert.Equal(t, genai.NewContentFromText("A cat and a dog.", genai.RoleModel), contents[3])
+	})
+
+	errs := map[string]Message{
+		"invalid base64":    {ID: "u1", Role: "user", Content: []ContentPart{{Type: "image", MimeType: "image/png", Data: "not base64!"}}},
+		"part without data": {ID: "u1", Role: "user", Content: []ContentPart{{Type: "image", MimeType: "image/png"}}},
+		"invalid arguments": {ID: "a1", Role: "assistant", ToolCalls: []events.ToolCall{{ID: "call-1", Function: events.Function{Name: "ocr", Arguments: "{"}}}},
+	}
+	for name, msg := range errs {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := convertMessagesToGenAIContents([]Message{msg})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), `message "`+msg.ID+`"`)
+		})
+	}
+}
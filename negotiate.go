@@ -0,0 +1,108 @@
+package aguigo
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Media types the handlers respond with, in order of preference
+const (
+	ContentTypeSSE    = "text/event-stream"
+	ContentTypeJSON   = "application/json"
+	ContentTypeNDJSON = "application/x-ndjson"
+)
+
+// responseContentTypes are the offers of both handlers; on a tie the
+// earlier one wins
+var responseContentTypes = []string{ContentTypeSSE, ContentTypeJSON, ContentTypeNDJSON, ContentTypeProto, ContentTypeResult}
+
+// negotiateResponse picks the response content type for r. If the client
+// accepts none of the offers it answers 406 with the supported types.
+func negotiateResponse(w http.ResponseWriter, r *http.Request) (string, bool) {
+	w.Header().Add("Vary", "Accept")
+
+	contentType := negotiateContentType(r.Header.Get("Accept"), responseContentTypes)
+	if contentType == "" {
+		msg := fmt.Sprintf("Not acceptable: supported types are %s", strings.Join(responseContentTypes, ", "))
+		http.Error(w, msg, http.StatusNotAcceptable)
+		return "", false
+	}
+	return contentType, true
+}
+
+// negotiateContentType picks the offer the Accept header ranks highest,
+// honouring q-values and wildcards. A missing header accepts anything. It
+// returns "" if no offer is acceptable.
+func negotiateContentType(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+	ranges := parseAccept(accept)
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(ranges, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// acceptRange is one media range of an Accept header
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept parses an Accept header, skipping malformed ranges
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok || typ == "" || subtype == "" {
+			continue
+		}
+
+		r := acceptRange{typ: strings.TrimSpace(typ), subtype: strings.TrimSpace(subtype), q: 1}
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(strings.TrimSpace(key), "q") {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil || q < 0 || q > 1 {
+					q = 0
+				}
+				r.q = q
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// acceptQuality returns the q-value of the most specific range matching
+// contentType, or 0 if none does
+func acceptQuality(ranges []acceptRange, contentType string) float64 {
+	typ, subtype, _ := strings.Cut(contentType, "/")
+
+	q, specificity := 0.0, 0
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 3
+		case r.typ == typ && r.subtype == "*":
+			s = 2
+		case r.typ == "*" && r.subtype == "*":
+			s = 1
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
@@ -0,0 +1,94 @@
+package aguigo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestNegotiateContentType(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ContentTypeSSE},
+		{"*/*", ContentTypeSSE},
+		{"text/event-stream", ContentTypeSSE},
+		{"application/json", ContentTypeJSON},
+		{"text/event-stream, application/json;q=0.9", ContentTypeSSE},
+		{"application/json, text/event-stream;q=0.5", ContentTypeJSON},
+		{"application/vnd.ag-ui.event+proto", ContentTypeProto},
+		{"application/*;q=0.8, text/event-stream;q=0.2", ContentTypeJSON},
+		{"text/event-stream;q=0, */*;q=0.1", ContentTypeJSON},
+		{"Text/Event-Stream", ContentTypeSSE},
+		{"application/x-ndjson", ContentTypeNDJSON},
+		{"application/x-ndjson, application/json;q=0.9", ContentTypeNDJSON},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", ContentTypeSSE},
+		{"application/json;q=0.5;charset=utf-8, text/event-stream;q=abc", ContentTypeJSON},
+		{"image/png", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			assert.Equal(t, tt.want, negotiateContentType(tt.accept, responseContentTypes))
+		})
+	}
+}
+
+func TestHandler_Negotiation(t *testing.T) {
+	handler := New(Config{EventSource: &MockEventSource{}})
+
+	serve := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"threadId":"thread-1","runId":"run-1"}`))
+		req.Header.Set("Accept", accept)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("browser fetch gets SSE", func(t *testing.T) {
+		rr := serve("text/event-stream, application/json;q=0.9")
+		assert.Equal(t, ContentTypeSSE, rr.Header().Get("Content-Type"))
+		assert.Equal(t, "Accept", rr.Header().Get("Vary"))
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		rr := serve(ContentTypeNDJSON)
+		assert.Equal(t, ContentTypeNDJSON, rr.Header().Get("Content-Type"))
+		assert.Equal(t, []string{"RUN_STARTED", "RUN_FINISHED"}, ndjsonTypes(t, rr.Body.String()))
+	})
+
+	t.Run("nothing acceptable", func(t *testing.T) {
+		rr := serve("text/html")
+		assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+		assert.Contains(t, rr.Body.String(), ContentTypeNDJSON)
+	})
+}
+
+func TestADKHandler_Negotiation(t *testing.T) {
+	handler := newTestADKHandler(t, []model.LLMResponse{
+		{Content: genai.NewContentFromText("Hello", genai.RoleModel)},
+	})
+
+	serve := func(accept string) *httptest.ResponseRecorder {
+		body := `{"threadId":"thread-1","runId":"run-1","messages":[{"id":"m1","role":"user","content":"Hi"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Accept", accept)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve("application/x-ndjson;q=1, application/json;q=0.1")
+	assert.Equal(t, ContentTypeNDJSON, rr.Header().Get("Content-Type"))
+	types := ndjsonTypes(t, rr.Body.String())
+	assert.Equal(t, "RUN_STARTED", types[0])
+	assert.Contains(t, types, "TEXT_MESSAGE_CONTENT")
+
+	assert.Equal(t, http.StatusNotAcceptable, serve("image/*").Code)
+}
@@ -0,0 +1,244 @@
+package aguigo
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ContentTypeProto is the AG-UI protobuf event stream: each event is an
+// ag_ui.Event message preceded by its length as a 4-byte big-endian integer
+const ContentTypeProto = "application/vnd.ag-ui.event+proto"
+
+// protoKind is how a JSON field of an event is encoded
+type protoKind int
+
+const (
+	protoString protoKind = iota
+	protoValue
+	protoPatch
+	protoMessages
+)
+
+// protoField maps a JSON field of an event to a protobuf field
+type protoField struct {
+	json   string
+	number protowire.Number
+	kind   protoKind
+}
+
+// protoEventSchema describes one event message of the AG-UI events.proto:
+// its field in the Event oneof, its EventType enum value and its fields
+// after base_event
+type protoEventSchema struct {
+	oneof     protowire.Number
+	eventType uint64
+	fields    []protoField
+}
+
+var protoEventSchemas = map[events.EventType]protoEventSchema{
+	events.EventTypeTextMessageStart:   {1, 0, []protoField{{"messageId", 2, protoString}, {"role", 3, protoString}}},
+	events.EventTypeTextMessageContent: {2, 1, []protoField{{"messageId", 2, protoString}, {"delta", 3, protoString}}},
+	events.EventTypeTextMessageEnd:     {3, 2, []protoField{{"messageId", 2, protoString}}},
+	events.EventTypeToolCallStart:      {4, 3, []protoField{{"toolCallId", 2, protoString}, {"toolCallName", 3, protoString}, {"parentMessageId", 4, protoString}}},
+	events.EventTypeToolCallArgs:       {5, 4, []protoField{{"toolCallId", 2, protoString}, {"delta", 3, protoString}}},
+	events.EventTypeToolCallEnd:        {6, 5, []protoField{{"toolCallId", 2, protoString}}},
+	events.EventTypeStateSnapshot:      {7, 6, []protoField{{"snapshot", 2, protoValue}}},
+	events.EventTypeStateDelta:         {8, 7, []protoField{{"delta", 2, protoPatch}}},
+	events.EventTypeMessagesSnapshot:   {9, 8, []protoField{{"messages", 2, protoMessages}}},
+	events.EventTypeRaw:                {10, 9, []protoField{{"event", 2, protoValue}, {"source", 3, protoString}}},
+	events.EventTypeCustom:             {11, 10, []protoField{{"name", 2, protoString}, {"value", 3, protoValue}}},
+	events.EventTypeRunStarted:         {12, 11, []protoField{{"threadId", 2, protoString}, {"runId", 3, protoString}}},
+	events.EventTypeRunFinished:        {13, 12, []protoField{{"threadId", 2, protoString}, {"runId", 3, protoString}, {"result", 4, protoValue}}},
+	events.EventTypeRunError:           {14, 13, []protoField{{"code", 2, protoString}, {"message", 3, protoString}}},
+	events.EventTypeStepStarted:        {15, 14, []protoField{{"stepName", 2, protoString}}},
+	events.EventTypeStepFinished:       {16, 15, []protoField{{"stepName", 2, protoString}}},
+	events.EventTypeTextMessageChunk:   {17, 16, []protoField{{"messageId", 2, protoString}, {"role", 3, protoString}, {"delta", 4, protoString}}},
+	events.EventTypeToolCallChunk:      {18, 17, []protoField{{"toolCallId", 2, protoString}, {"toolCallName", 3, protoString}, {"parentMessageId", 4, protoString}, {"delta", 5, protoString}}},
+}
+
+// protoPatchOps are the JsonPatchOperationType enum values
+var protoPatchOps = map[string]uint64{"add": 0, "remove": 1, "replace": 2, "move": 3, "copy": 4, "test": 5}
+
+// ProtoRawSource is the source of the RAW events the protobuf encoding wraps
+// other events in. events.proto has no message for thinking, activity and
+// tool call result events, so each is sent as a RAW event with this source
+// whose event field is the wrapped event's JSON object, including its type.
+// Decoders should unwrap RAW events with this source; RAW events from
+// anywhere else never carry it.
+const ProtoRawSource = "ag-ui"
+
+// encodeProtoEvent encodes an event as an ag_ui.Event message. Events
+// without a protobuf message are wrapped in a RAW event with source
+// ProtoRawSource.
+func encodeProtoEvent(evt events.Event) ([]byte, error) {
+	data, err := evt.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	schema, ok := protoEventSchemas[evt.Type()]
+	if !ok {
+		schema = protoEventSchemas[events.EventTypeRaw]
+		fields = map[string]any{"event": fields, "source": ProtoRawSource, "timestamp": fields["timestamp"]}
+	}
+
+	// base_event: type, timestamp, raw_event
+	var base []byte
+	base = protowire.AppendTag(base, 1, protowire.VarintType)
+	base = protowire.AppendVarint(base, schema.eventType)
+	if ts, ok := fields["timestamp"].(float64); ok {
+		base = protowire.AppendTag(base, 2, protowire.VarintType)
+		base = protowire.AppendVarint(base, uint64(int64(ts)))
+	}
+	if raw, ok := fields["rawEvent"]; ok {
+		if base, err = appendProtoValue(base, 3, raw); err != nil {
+			return nil, err
+		}
+	}
+
+	msg := protowire.AppendTag(nil, 1, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, base)
+
+	for _, f := range schema.fields {
+		v, ok := fields[f.json]
+		if !ok || v == nil {
+			continue
+		}
+		switch f.kind {
+		case protoString:
+			msg = appendProtoString(msg, f.number, fmt.Sprint(v))
+		case protoValue:
+			msg, err = appendProtoValue(msg, f.number, v)
+		case protoPatch:
+			msg, err = appendProtoPatch(msg, f.number, v)
+		case protoMessages:
+			msg, err = appendProtoMessages(msg, f.number, v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encoding %s.%s: %w", evt.Type(), f.json, err)
+		}
+	}
+
+	out := protowire.AppendTag(nil, schema.oneof, protowire.BytesType)
+	return protowire.AppendBytes(out, msg), nil
+}
+
+// writeProtoFrame writes one encoded event with its length prefix
+func writeProtoFrame(w io.Writer, data []byte) error {
+	var prefix [4]byte
+	binary.BigEndian.PutUint32(prefix[:], uint32(len(data)))
+	if _, err := w.Write(prefix[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func appendProtoString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// appendProtoValue appends v as a google.protobuf.Value
+func appendProtoValue(b []byte, num protowire.Number, v any) ([]byte, error) {
+	value, err := structpb.NewValue(v)
+	if err != nil {
+		return nil, err
+	}
+	data, err := proto.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, data), nil
+}
+
+// appendProtoPatch appends JSON patch operations as ag_ui.JsonPatchOperation
+func appendProtoPatch(b []byte, num protowire.Number, v any) ([]byte, error) {
+	ops, _ := v.([]any)
+	for _, item := range ops {
+		op, _ := item.(map[string]any)
+		name, _ := op["op"].(string)
+		opType, ok := protoPatchOps[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown patch op %q", name)
+		}
+
+		var m []byte
+		m = protowire.AppendTag(m, 1, protowire.VarintType)
+		m = protowire.AppendVarint(m, opType)
+		path, _ := op["path"].(string)
+		m = appendProtoString(m, 2, path)
+		from, _ := op["from"].(string)
+		m = appendProtoString(m, 3, from)
+		if value, ok := op["value"]; ok {
+			var err error
+			if m, err = appendProtoValue(m, 4, value); err != nil {
+				return nil, err
+			}
+		}
+
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, m)
+	}
+	return b, nil
+}
+
+// appendProtoMessages appends messages as ag_ui.Message
+func appendProtoMessages(b []byte, num protowire.Number, v any) ([]byte, error) {
+	messages, _ := v.([]any)
+	for _, item := range messages {
+		msg, _ := item.(map[string]any)
+
+		var m []byte
+		for _, f := range []struct {
+			json   string
+			number protowire.Number
+		}{{"id", 1}, {"role", 2}, {"content", 3}, {"name", 4}, {"toolCallId", 6}, {"error", 7}} {
+			if s, ok := msg[f.json].(string); ok {
+				m = appendProtoString(m, f.number, s)
+			}
+		}
+
+		calls, _ := msg["toolCalls"].([]any)
+		for _, c := range calls {
+			call, _ := c.(map[string]any)
+			fn, _ := call["function"].(map[string]any)
+
+			var function []byte
+			name, _ := fn["name"].(string)
+			function = appendProtoString(function, 1, name)
+			args, _ := fn["arguments"].(string)
+			function = appendProtoString(function, 2, args)
+
+			var tc []byte
+			id, _ := call["id"].(string)
+			tc = appendProtoString(tc, 1, id)
+			typ, _ := call["type"].(string)
+			tc = appendProtoString(tc, 2, typ)
+			tc = protowire.AppendTag(tc, 3, protowire.BytesType)
+			tc = protowire.AppendBytes(tc, function)
+
+			m = protowire.AppendTag(m, 5, protowire.BytesType)
+			m = protowire.AppendBytes(m, tc)
+		}
+
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, m)
+	}
+	return b, nil
+}
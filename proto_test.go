@@ -0,0 +1,215 @@
+package aguigo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// protoMessage is a decoded protobuf message: raw bytes for length-delimited
+// fields and numbers for varints, by field number
+type protoMessage map[protowire.Number][]any
+
+func decodeProto(t *testing.T, b []byte) protoMessage {
+	t.Helper()
+
+	m := protoMessage{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			require.GreaterOrEqual(t, n, 0)
+			m[num] = append(m[num], v)
+			b = b[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			require.GreaterOrEqual(t, n, 0)
+			m[num] = append(m[num], v)
+			b = b[n:]
+		default:
+			t.Fatalf("unexpected wire type %v", typ)
+		}
+	}
+	return m
+}
+
+// decodeEvent returns the oneof field number and the event message
+func decodeEvent(t *testing.T, data []byte) (protowire.Number, protoMessage) {
+	t.Helper()
+
+	outer := decodeProto(t, data)
+	require.Len(t, outer, 1)
+	for num, v := range outer {
+		return num, decodeProto(t, v[0].([]byte))
+	}
+	return 0, nil
+}
+
+// readProtoFrames splits a length-prefixed stream
+func readProtoFrames(t *testing.T, r io.Reader) [][]byte {
+	t.Helper()
+
+	var frames [][]byte
+	for {
+		var prefix [4]byte
+		if _, err := io.ReadFull(r, prefix[:]); err == io.EOF {
+			return frames
+		} else {
+			require.NoError(t, err)
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(prefix[:]))
+		_, err := io.ReadFull(r, frame)
+		require.NoError(t, err)
+		frames = append(frames, frame)
+	}
+}
+
+func TestEncodeProtoEvent(t *testing.T) {
+	t.Run("text message content", func(t *testing.T) {
+		data, err := encodeProtoEvent(events.NewTextMessageContentEvent("m1", "Hello"))
+		require.NoError(t, err)
+
+		oneof, msg := decodeEvent(t, data)
+		assert.Equal(t, protowire.Number(2), oneof)
+		assert.Equal(t, []byte("m1"), msg[2][0])
+		assert.Equal(t, []byte("Hello"), msg[3][0])
+
+		base := decodeProto(t, msg[1][0].([]byte))
+		assert.Equal(t, uint64(1), base[1][0])
+		assert.NotEmpty(t, base[2], "timestamp")
+	})
+
+	t.Run("state snapshot", func(t *testing.T) {
+		data, err := encodeProtoEvent(events.NewStateSnapshotEvent(map[string]any{"count": 2}))
+		require.NoError(t, err)
+
+		oneof, msg := decodeEvent(t, data)
+		assert.Equal(t, protowire.Number(7), oneof)
+
+		var value structpb.Value
+		require.NoError(t, proto.Unmarshal(msg[2][0].([]byte), &value))
+		assert.Equal(t, map[string]any{"count": float64(2)}, value.AsInterface())
+	})
+
+	t.Run("state delta", func(t *testing.T) {
+		data, err := encodeProtoEvent(events.NewStateDeltaEvent([]events.JSONPatchOperation{
+			{Op: "replace", Path: "/count", Value: 3},
+			{Op: "move", From: "/a", Path: "/b"},
+		}))
+		require.NoError(t, err)
+
+		_, msg := decodeEvent(t, data)
+		require.Len(t, msg[2], 2)
+		op := decodeProto(t, msg[2][0].([]byte))
+		assert.Equal(t, uint64(2), op[1][0])
+		assert.Equal(t, []byte("/count"), op[2][0])
+		op = decodeProto(t, msg[2][1].([]byte))
+		assert.Equal(t, uint64(3), op[1][0])
+		assert.Equal(t, []byte("/a"), op[3][0])
+	})
+
+	t.Run("messages snapshot", func(t *testing.T) {
+		content := "Checking"
+		data, err := encodeProtoEvent(events.NewMessagesSnapshotEvent([]events.Message{{
+			ID: "m1", Role: "assistant", Content: &content,
+			ToolCalls: []events.ToolCall{{ID: "call-1", Type: "function", Function: events.Function{Name: "search", Arguments: "{}"}}},
+		}}))
+		require.NoError(t, err)
+
+		_, msg := decodeEvent(t, data)
+		m := decodeProto(t, msg[2][0].([]byte))
+		assert.Equal(t, []byte("assistant"), m[2][0])
+		assert.Equal(t, []byte("Checking"), m[3][0])
+
+		call := decodeProto(t, m[5][0].([]byte))
+		assert.Equal(t, []byte("call-1"), call[1][0])
+		fn := decodeProto(t, call[3][0].([]byte))
+		assert.Equal(t, []byte("search"), fn[1][0])
+	})
+
+	t.Run("events without a message are wrapped in RAW", func(t *testing.T) {
+		for _, evt := range []events.Event{
+			events.NewThinkingStartEvent(),
+			events.NewToolCallResultEvent("msg-1", "call-1", "42"),
+		} {
+			data, err := encodeProtoEvent(evt)
+			require.NoError(t, err)
+
+			oneof, msg := decodeEvent(t, data)
+			assert.Equal(t, protowire.Number(10), oneof)
+			assert.Equal(t, ProtoRawSource, string(msg[3][0].([]byte)))
+
+			// The wrapped JSON decodes back to the original event
+			var value structpb.Value
+			require.NoError(t, proto.Unmarshal(msg[2][0].([]byte), &value))
+			wrapped, err := value.GetStructValue().MarshalJSON()
+			require.NoError(t, err)
+			want, err := evt.ToJSON()
+			require.NoError(t, err)
+			assert.JSONEq(t, string(want), string(wrapped))
+		}
+	})
+}
+
+func TestHandler_Proto(t *testing.T) {
+	handler := New(Config{
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return textSeq("m1", "Hi")
+		}),
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"threadId":"thread-1","runId":"run-1"}`))
+	req.Header.Set("Accept", ContentTypeProto)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, ContentTypeProto, rr.Header().Get("Content-Type"))
+	var oneofs []protowire.Number
+	for _, frame := range readProtoFrames(t, rr.Body) {
+		oneof, _ := decodeEvent(t, frame)
+		oneofs = append(oneofs, oneof)
+	}
+	assert.Equal(t, []protowire.Number{12, 1, 2, 3, 13}, oneofs)
+}
+
+func TestADKHandler_Proto(t *testing.T) {
+	handler := newTestADKHandler(t, []model.LLMResponse{
+		{Content: genai.NewContentFromText("Hello", genai.RoleModel)},
+	})
+
+	body := `{"threadId":"thread-1","runId":"run-1","messages":[{"id":"m1","role":"user","content":"Hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Accept", "application/vnd.ag-ui.event+proto, application/json;q=0.5")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, ContentTypeProto, rr.Header().Get("Content-Type"))
+	frames := readProtoFrames(t, bytes.NewReader(rr.Body.Bytes()))
+	require.NotEmpty(t, frames)
+
+	var sawContent bool
+	for _, frame := range frames {
+		if oneof, msg := decodeEvent(t, frame); oneof == 2 {
+			sawContent = true
+			assert.Equal(t, []byte("Hello"), msg[3][0])
+		}
+	}
+	assert.True(t, sawContent)
+}
@@ -0,0 +1,474 @@
+package aguigo
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// defaultRedactionWindow is how many trailing bytes of a stream are held back
+// so that a pattern split across chunks is still detected
+const defaultRedactionWindow = 64
+
+// PIIDetector finds one kind of sensitive data in text
+type PIIDetector struct {
+	// Name identifies the detector and is passed to the RedactionStrategy
+	Name string
+	// Pattern matches candidate values
+	Pattern *regexp.Regexp
+	// Validate optionally rejects false positives, e.g. a Luhn check for card numbers
+	Validate func(match string) bool
+}
+
+var (
+	// EmailDetector matches email addresses
+	EmailDetector = PIIDetector{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	}
+	// PhoneDetector matches international and North American style phone numbers
+	PhoneDetector = PIIDetector{
+		Name:    "phone",
+		Pattern: regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{2,4}\)[\s.\-]?|\d{2,4}[\s.\-])\d{3,4}[\s.\-]?\d{3,4}`),
+	}
+	// CreditCardDetector matches 13 to 19 digit card numbers that pass the Luhn check
+	CreditCardDetector = PIIDetector{
+		Name:     "credit_card",
+		Pattern:  regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		Validate: luhnValid,
+	}
+)
+
+// DefaultPIIDetectors returns the built-in email, phone and card number detectors.
+// Cards are listed first so their digits are not claimed by the phone detector.
+func DefaultPIIDetectors() []PIIDetector {
+	return []PIIDetector{CreditCardDetector, EmailDetector, PhoneDetector}
+}
+
+// RedactionStrategy returns the replacement for a detected value
+type RedactionStrategy func(detector, match string) string
+
+// RedactWithLabel replaces values with a label such as [REDACTED_EMAIL]
+func RedactWithLabel() RedactionStrategy {
+	return func(detector, _ string) string {
+		return "[REDACTED_" + strings.ToUpper(detector) + "]"
+	}
+}
+
+// RedactWithMask replaces every character of the value with mask, keeping the last keep characters
+func RedactWithMask(mask rune, keep int) RedactionStrategy {
+	keep = max(keep, 0)
+	return func(_, match string) string {
+		runes := []rune(match)
+		for i := 0; i < len(runes)-keep; i++ {
+			runes[i] = mask
+		}
+		return string(runes)
+	}
+}
+
+// RedactWithText replaces values with a fixed string
+func RedactWithText(replacement string) RedactionStrategy {
+	return func(string, string) string { return replacement }
+}
+
+// RedactionConfig configures a PIIRedactor
+type RedactionConfig struct {
+	// Detectors to run; defaults to DefaultPIIDetectors
+	Detectors []PIIDetector
+	// Strategy produces replacements; defaults to RedactWithLabel
+	Strategy RedactionStrategy
+	// Window is the number of trailing bytes held back per stream; defaults to 64.
+	// Values longer than the window that arrive split across chunks may be missed.
+	Window int
+	// Apply decides whether a request is redacted; nil redacts every request
+	Apply func(ctx HandlerContext) bool
+}
+
+// PIIRedactor is an EventInterceptor that redacts sensitive values from the
+// deltas of TEXT_MESSAGE_CONTENT, TOOL_CALL_ARGS, TEXT_MESSAGE_CHUNK,
+// TOOL_CALL_CHUNK and THINKING_TEXT_MESSAGE_CONTENT events and from the
+// content of TOOL_CALL_RESULT events. Because a value can be split across
+// chunks, the tail of each message, tool call or thinking message is held
+// back and only released once more text arrives or it ends. A run of chunk
+// events ends at the first event that doesn't continue it. RAW events carry
+// the source's payload as is, so they are dropped.
+type PIIRedactor struct {
+	detectors []PIIDetector
+	strategy  RedactionStrategy
+	window    int
+	apply     func(ctx HandlerContext) bool
+
+	mu   sync.Mutex
+	runs map[string]*redactionRun
+}
+
+// redactionRun holds the pending text of each open stream in a run
+type redactionRun struct {
+	pending map[string]*pendingStream
+	order   []string
+	// chunk is the stream key of the open chunk stream, if any
+	chunk string
+}
+
+type pendingStream struct {
+	id         string
+	isToolCall bool
+	chunk      bool
+	thinking   bool
+	text       string
+}
+
+// thinkingStreamKey keys the open thinking message, which has no ID
+const thinkingStreamKey = "thinking"
+
+type textSpan struct {
+	start, end int
+	detector   string
+}
+
+// NewPIIRedactor creates a redaction interceptor
+func NewPIIRedactor(config RedactionConfig) *PIIRedactor {
+	detectors := config.Detectors
+	if len(detectors) == 0 {
+		detectors = DefaultPIIDetectors()
+	}
+	strategy := config.Strategy
+	if strategy == nil {
+		strategy = RedactWithLabel()
+	}
+	window := config.Window
+	if window <= 0 {
+		window = defaultRedactionWindow
+	}
+
+	return &PIIRedactor{
+		detectors: detectors,
+		strategy:  strategy,
+		window:    window,
+		apply:     config.Apply,
+		runs:      make(map[string]*redactionRun),
+	}
+}
+
+// Redact redacts a complete piece of text
+func (r *PIIRedactor) Redact(text string) string {
+	return r.replace(text, r.find(text))
+}
+
+// Intercept implements EventInterceptor
+func (r *PIIRedactor) Intercept(ctx HandlerContext, evt events.Event) []events.Event {
+	if r.apply != nil && !r.apply(ctx) {
+		return []events.Event{evt}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := ctx.ThreadID + "/" + ctx.RunID
+
+	switch e := evt.(type) {
+	case *events.TextMessageChunkEvent:
+		id := r.chunkID(key, e.MessageID, false)
+		return r.pushChunk(key, pendingStream{id: id, chunk: true}, e.Delta, func(delta *string) events.Event {
+			c := *e
+			c.Delta = delta
+			return &c
+		})
+	case *events.ToolCallChunkEvent:
+		id := r.chunkID(key, e.ToolCallID, true)
+		return r.pushChunk(key, pendingStream{id: id, isToolCall: true, chunk: true}, e.Delta, func(delta *string) events.Event {
+			c := *e
+			c.Delta = delta
+			return &c
+		})
+	}
+
+	// Any other event ends the open chunk stream
+	result := r.endChunk(key, "")
+
+	switch e := evt.(type) {
+	case *events.RawEvent:
+		return result
+	case *events.ToolCallResultEvent:
+		c := *e
+		c.Content = r.Redact(e.Content)
+		return append(result, &c)
+	case *events.ThinkingTextMessageContentEvent:
+		stream, _ := r.stream(key, thinkingStreamKey, pendingStream{thinking: true})
+		return append(result, deltaEvent(stream, r.advance(stream, e.Delta))...)
+	case *events.ThinkingTextMessageEndEvent, *events.ThinkingEndEvent:
+		result = append(result, r.release(key, thinkingStreamKey)...)
+	case *events.TextMessageContentEvent:
+		return append(result, r.push(key, e.MessageID, false, e.Delta)...)
+	case *events.ToolCallArgsEvent:
+		return append(result, r.push(key, e.ToolCallID, true, e.Delta)...)
+	case *events.TextMessageEndEvent:
+		result = append(result, r.release(key, e.MessageID)...)
+	case *events.ToolCallEndEvent:
+		result = append(result, r.release(key, e.ToolCallID)...)
+	case *events.RunFinishedEvent, *events.RunErrorEvent:
+		result = append(result, r.releaseAll(key)...)
+	}
+
+	return append(result, evt)
+}
+
+// Flush implements EventFlusher, releasing anything still held for the run
+func (r *PIIRedactor) Flush(ctx HandlerContext) []events.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.releaseAll(ctx.ThreadID + "/" + ctx.RunID)
+}
+
+// push appends a delta to its stream and emits the part that is safe to release
+func (r *PIIRedactor) push(key, id string, isToolCall bool, delta string) []events.Event {
+	stream, _ := r.stream(key, id, pendingStream{id: id, isToolCall: isToolCall})
+	return deltaEvent(stream, r.advance(stream, delta))
+}
+
+// pushChunk appends the delta of a chunk event to its chunk stream. The first
+// chunk of a stream is always passed on, since it carries the stream's ID,
+// role or name; later chunks only when part of their text can be released.
+func (r *PIIRedactor) pushChunk(key string, proto pendingStream, delta *string, withDelta func(*string) events.Event) []events.Event {
+	streamKey := chunkStreamKey(proto.id, proto.isToolCall)
+	result := r.endChunk(key, streamKey)
+
+	stream, first := r.stream(key, streamKey, proto)
+	r.runs[key].chunk = streamKey
+
+	var text string
+	if delta != nil {
+		text = *delta
+	}
+	released := r.advance(stream, text)
+	if !first && released == "" {
+		return result
+	}
+	return append(result, withDelta(optionalString(released)))
+}
+
+// chunkID returns the ID of a chunk event, which continues the open chunk
+// stream of its kind when the event has no ID of its own
+func (r *PIIRedactor) chunkID(key string, id *string, isToolCall bool) string {
+	if id != nil {
+		return *id
+	}
+	if run := r.runs[key]; run != nil && run.chunk != "" {
+		if stream := run.pending[run.chunk]; stream.isToolCall == isToolCall {
+			return stream.id
+		}
+	}
+	return ""
+}
+
+// endChunk releases the open chunk stream unless it is next
+func (r *PIIRedactor) endChunk(key, next string) []events.Event {
+	run := r.runs[key]
+	if run == nil || run.chunk == "" || run.chunk == next {
+		return nil
+	}
+	streamKey := run.chunk
+	run.chunk = ""
+	return r.release(key, streamKey)
+}
+
+// stream returns the pending stream under streamKey, creating it from proto
+// if it doesn't exist yet
+func (r *PIIRedactor) stream(key, streamKey string, proto pendingStream) (*pendingStream, bool) {
+	run := r.runs[key]
+	if run == nil {
+		run = &redactionRun{pending: make(map[string]*pendingStream)}
+		r.runs[key] = run
+	}
+	if stream := run.pending[streamKey]; stream != nil {
+		return stream, false
+	}
+	stream := &proto
+	run.pending[streamKey] = stream
+	run.order = append(run.order, streamKey)
+	return stream, true
+}
+
+// advance appends delta to a stream and returns the redacted text that can
+// be released
+func (r *PIIRedactor) advance(stream *pendingStream, delta string) string {
+	stream.text += delta
+	emit, rest := r.split(stream.text)
+	stream.text = rest
+	return emit
+}
+
+// release emits everything pending for a stream and forgets it
+func (r *PIIRedactor) release(key, streamKey string) []events.Event {
+	run := r.runs[key]
+	if run == nil {
+		return nil
+	}
+	stream := run.pending[streamKey]
+	if stream == nil {
+		return nil
+	}
+
+	delete(run.pending, streamKey)
+	if run.chunk == streamKey {
+		run.chunk = ""
+	}
+	for i, pendingKey := range run.order {
+		if pendingKey == streamKey {
+			run.order = append(run.order[:i], run.order[i+1:]...)
+			break
+		}
+	}
+	if len(run.pending) == 0 {
+		delete(r.runs, key)
+	}
+
+	return deltaEvent(stream, r.Redact(stream.text))
+}
+
+// releaseAll emits everything pending for a run in the order the streams started
+func (r *PIIRedactor) releaseAll(key string) []events.Event {
+	run := r.runs[key]
+	if run == nil {
+		return nil
+	}
+	var result []events.Event
+	for _, streamKey := range append([]string(nil), run.order...) {
+		result = append(result, r.release(key, streamKey)...)
+	}
+	delete(r.runs, key)
+	return result
+}
+
+// split returns the redacted prefix of text that can be released now and the
+// raw tail to hold back. A detected value straddling the cut is held back whole.
+func (r *PIIRedactor) split(text string) (string, string) {
+	cut := len(text) - r.window
+	if cut <= 0 {
+		return "", text
+	}
+
+	spans := r.find(text)
+	for _, sp := range spans {
+		if sp.start < cut && sp.end > cut {
+			cut = sp.start
+		}
+	}
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+
+	var released []textSpan
+	for _, sp := range spans {
+		if sp.end <= cut {
+			released = append(released, sp)
+		}
+	}
+
+	return r.replace(text[:cut], released), text[cut:]
+}
+
+// find returns the non-overlapping detected spans in text, ordered by position.
+// On overlap the detector listed first wins.
+func (r *PIIRedactor) find(text string) []textSpan {
+	var spans []textSpan
+	for _, d := range r.detectors {
+		for _, loc := range d.Pattern.FindAllStringIndex(text, -1) {
+			if d.Validate != nil && !d.Validate(text[loc[0]:loc[1]]) {
+				continue
+			}
+			candidate := textSpan{start: loc[0], end: loc[1], detector: d.Name}
+			overlaps := false
+			for _, sp := range spans {
+				if candidate.start < sp.end && sp.start < candidate.end {
+					overlaps = true
+					break
+				}
+			}
+			if !overlaps {
+				spans = append(spans, candidate)
+			}
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	return spans
+}
+
+func (r *PIIRedactor) replace(text string, spans []textSpan) string {
+	if len(spans) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, sp := range spans {
+		b.WriteString(text[last:sp.start])
+		b.WriteString(r.strategy(sp.detector, text[sp.start:sp.end]))
+		last = sp.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// deltaEvent builds the content, args or chunk event for a released delta
+func deltaEvent(stream *pendingStream, delta string) []events.Event {
+	if delta == "" {
+		return nil
+	}
+	switch {
+	case stream.thinking:
+		return []events.Event{events.NewThinkingTextMessageContentEvent(delta)}
+	case stream.chunk && stream.isToolCall:
+		evt := events.NewToolCallChunkEvent().WithToolCallChunkDelta(delta)
+		if stream.id != "" {
+			evt = evt.WithToolCallChunkID(stream.id)
+		}
+		return []events.Event{evt}
+	case stream.chunk:
+		return []events.Event{events.NewTextMessageChunkEvent(optionalString(stream.id), nil, &delta)}
+	case stream.isToolCall:
+		return []events.Event{events.NewToolCallArgsEvent(stream.id, delta)}
+	}
+	return []events.Event{events.NewTextMessageContentEvent(stream.id, delta)}
+}
+
+// chunkStreamKey keys chunk streams apart from the regular streams with the same ID
+func chunkStreamKey(id string, isToolCall bool) string {
+	if isToolCall {
+		return "chunk/tool/" + id
+	}
+	return "chunk/text/" + id
+}
+
+// optionalString returns nil for an empty string
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
@@ -0,0 +1,260 @@
+package aguigo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectText concatenates the content deltas for a message
+func collectText(evts []events.Event, messageID string) string {
+	var b strings.Builder
+	for _, evt := range evts {
+		if c, ok := evt.(*events.TextMessageContentEvent); ok && c.MessageID == messageID {
+			b.WriteString(c.Delta)
+		}
+	}
+	return b.String()
+}
+
+func TestPIIRedactor_Redact(t *testing.T) {
+	redactor := NewPIIRedactor(RedactionConfig{})
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"email", "Mail jane.doe@example.com today", "Mail [REDACTED_EMAIL] today"},
+		{"phone", "Call +1 415-555-0132 now", "Call [REDACTED_PHONE] now"},
+		{"card", "Card 4111 1111 1111 1111 on file", "Card [REDACTED_CREDIT_CARD] on file"},
+		{"invalid card", "Order 4111111111111112 shipped", "Order 4111111111111112 shipped"},
+		{"no pii", "Nothing to see here", "Nothing to see here"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, redactor.Redact(tt.in))
+		})
+	}
+}
+
+func TestPIIRedactor_Strategies(t *testing.T) {
+	t.Run("mask", func(t *testing.T) {
+		redactor := NewPIIRedactor(RedactionConfig{
+			Detectors: []PIIDetector{CreditCardDetector},
+			Strategy:  RedactWithMask('*', 4),
+		})
+		assert.Equal(t, "***************1111", redactor.Redact("4111-1111-1111-1111"))
+	})
+
+	t.Run("fixed text", func(t *testing.T) {
+		redactor := NewPIIRedactor(RedactionConfig{
+			Detectors: []PIIDetector{EmailDetector},
+			Strategy:  RedactWithText("<hidden>"),
+		})
+		assert.Equal(t, "to <hidden>", redactor.Redact("to a@b.io"))
+	})
+
+	t.Run("negative keep masks everything", func(t *testing.T) {
+		redactor := NewPIIRedactor(RedactionConfig{
+			Detectors: []PIIDetector{EmailDetector},
+			Strategy:  RedactWithMask('#', -2),
+		})
+		assert.Equal(t, "to ######", redactor.Redact("to a@b.io"))
+	})
+}
+
+func TestPIIRedactor_Streaming(t *testing.T) {
+	hctx := HandlerContext{ThreadID: "thread-1", RunID: "run-1"}
+
+	t.Run("detects values split across chunks", func(t *testing.T) {
+		redactor := NewPIIRedactor(RedactionConfig{Window: 16})
+
+		var out []events.Event
+		chunks := []string{"Please contact jane.", "doe@exam", "ple.com for details about the order. Thanks!"}
+		for _, chunk := range chunks {
+			out = append(out, redactor.Intercept(hctx, events.NewTextMessageContentEvent("msg-1", chunk))...)
+		}
+		out = append(out, redactor.Intercept(hctx, events.NewTextMessageEndEvent("msg-1"))...)
+
+		assert.Equal(t, "Please contact [REDACTED_EMAIL] for details about the order. Thanks!", collectText(out, "msg-1"))
+		assert.Equal(t, events.EventTypeTextMessageEnd, out[len(out)-1].Type())
+		for _, evt := range out {
+			if c, ok := evt.(*events.TextMessageContentEvent); ok {
+				assert.NotEmpty(t, c.Delta)
+			}
+		}
+	})
+
+	t.Run("redacts tool call args", func(t *testing.T) {
+		redactor := NewPIIRedactor(RedactionConfig{})
+
+		var out []events.Event
+		out = append(out, redactor.Intercept(hctx, events.NewToolCallArgsEvent("call-1", `{"email":"bob@`))...)
+		out = append(out, redactor.Intercept(hctx, events.NewToolCallArgsEvent("call-1", `corp.io"}`))...)
+		out = append(out, redactor.Intercept(hctx, events.NewToolCallEndEvent("call-1"))...)
+
+		var args strings.Builder
+		for _, evt := range out {
+			if a, ok := evt.(*events.ToolCallArgsEvent); ok {
+				args.WriteString(a.Delta)
+			}
+		}
+		assert.Equal(t, `{"email":"[REDACTED_EMAIL]"}`, args.String())
+	})
+
+	t.Run("redacts chunk events", func(t *testing.T) {
+		redactor := NewPIIRedactor(RedactionConfig{Window: 16})
+		msgID, role := "msg-4", "assistant"
+		callID, name := "call-2", "notify"
+		str := func(s string) *string { return &s }
+
+		var out []events.Event
+		for _, evt := range []events.Event{
+			events.NewTextMessageChunkEvent(&msgID, &role, str("Write to jane.")),
+			events.NewTextMessageChunkEvent(nil, nil, str("doe@example.com about the refund, please.")),
+			events.NewToolCallChunkEvent().WithToolCallChunkID(callID).WithToolCallChunkName(name).WithToolCallChunkDelta(`{"to":"jane.doe@`),
+			events.NewToolCallChunkEvent().WithToolCallChunkID(callID).WithToolCallChunkDelta(`example.com"}`),
+			events.NewRunFinishedEvent("thread-1", "run-1"),
+		} {
+			out = append(out, redactor.Intercept(hctx, evt)...)
+		}
+
+		var text, args strings.Builder
+		for _, evt := range out {
+			switch e := evt.(type) {
+			case *events.TextMessageChunkEvent:
+				if e.Delta != nil {
+					text.WriteString(*e.Delta)
+				}
+			case *events.ToolCallChunkEvent:
+				if e.Delta != nil {
+					args.WriteString(*e.Delta)
+				}
+			}
+		}
+		assert.Equal(t, "Write to [REDACTED_EMAIL] about the refund, please.", text.String())
+		assert.Equal(t, `{"to":"[REDACTED_EMAIL]"}`, args.String())
+
+		// The first chunk of each stream keeps its ID, role and name, and the
+		// text is released before the tool call starts
+		first, ok := out[0].(*events.TextMessageChunkEvent)
+		require.True(t, ok)
+		assert.Equal(t, msgID, *first.MessageID)
+		assert.Equal(t, role, *first.Role)
+		var types []events.EventType
+		for _, evt := range out {
+			if len(types) == 0 || types[len(types)-1] != evt.Type() {
+				types = append(types, evt.Type())
+			}
+		}
+		assert.Equal(t, []events.EventType{events.EventTypeTextMessageChunk, events.EventTypeToolCallChunk, events.EventTypeRunFinished}, types)
+		for _, evt := range out {
+			if c, ok := evt.(*events.ToolCallChunkEvent); ok && c.ToolCallName != nil {
+				assert.Equal(t, name, *c.ToolCallName)
+			}
+		}
+		assert.Empty(t, redactor.runs)
+	})
+
+	t.Run("redacts tool call results", func(t *testing.T) {
+		redactor := NewPIIRedactor(RedactionConfig{})
+
+		out := redactor.Intercept(hctx, events.NewToolCallResultEvent("msg-5", "call-3", `{"owner":"bob@corp.io"}`))
+		require.Len(t, out, 1)
+		assert.Equal(t, `{"owner":"[REDACTED_EMAIL]"}`, out[0].(*events.ToolCallResultEvent).Content)
+		assert.Equal(t, "call-3", out[0].(*events.ToolCallResultEvent).ToolCallID)
+	})
+
+	t.Run("redacts thinking text", func(t *testing.T) {
+		redactor := NewPIIRedactor(RedactionConfig{Window: 16})
+
+		var out []events.Event
+		for _, evt := range []events.Event{
+			events.NewThinkingStartEvent(),
+			events.NewThinkingTextMessageStartEvent(),
+			events.NewThinkingTextMessageContentEvent("The user is jane."),
+			events.NewThinkingTextMessageContentEvent("doe@example.com, so I will look up her orders."),
+			events.NewThinkingTextMessageEndEvent(),
+			events.NewThinkingEndEvent(),
+		} {
+			out = append(out, redactor.Intercept(hctx, evt)...)
+		}
+
+		var text strings.Builder
+		for _, evt := range out {
+			if c, ok := evt.(*events.ThinkingTextMessageContentEvent); ok {
+				text.WriteString(c.Delta)
+			}
+		}
+		assert.Equal(t, "The user is [REDACTED_EMAIL], so I will look up her orders.", text.String())
+		assert.Equal(t, events.EventTypeThinkingTextMessageEnd, out[len(out)-2].Type())
+		assert.Empty(t, redactor.runs)
+	})
+
+	t.Run("drops raw events", func(t *testing.T) {
+		redactor := NewPIIRedactor(RedactionConfig{})
+
+		assert.Empty(t, redactor.Intercept(hctx, events.NewRawEvent(map[string]any{"text": "jane@example.com"})))
+	})
+
+	t.Run("flush releases held text", func(t *testing.T) {
+		redactor := NewPIIRedactor(RedactionConfig{})
+
+		out := redactor.Intercept(hctx, events.NewTextMessageContentEvent("msg-2", "reach me at 415-555-0132"))
+		assert.Empty(t, out)
+
+		out = redactor.Flush(hctx)
+		require.Len(t, out, 1)
+		assert.Equal(t, "reach me at [REDACTED_PHONE]", collectText(out, "msg-2"))
+	})
+
+	t.Run("skips requests not selected by Apply", func(t *testing.T) {
+		redactor := NewPIIRedactor(RedactionConfig{
+			Apply: func(ctx HandlerContext) bool { return ctx.UserID != "admin" },
+		})
+
+		evt := events.NewTextMessageContentEvent("msg-3", "admin@example.com")
+		out := redactor.Intercept(HandlerContext{ThreadID: "t", RunID: "r", UserID: "admin"}, evt)
+		require.Len(t, out, 1)
+		assert.Same(t, evt, out[0])
+	})
+}
+
+func TestPIIRedactor_Handler(t *testing.T) {
+	source := &MockEventSource{
+		RunFunc: func(ctx HandlerContext, input RunAgentInput) <-chan events.Event {
+			ch := make(chan events.Event, 6)
+			ch <- events.NewRunStartedEvent(ctx.ThreadID, ctx.RunID)
+			ch <- events.NewTextMessageStartEvent("msg-1", events.WithRole("assistant"))
+			ch <- events.NewTextMessageContentEvent("msg-1", "Card: 4111 1111 ")
+			ch <- events.NewTextMessageContentEvent("msg-1", "1111 1111")
+			ch <- events.NewTextMessageEndEvent("msg-1")
+			ch <- events.NewRunFinishedEvent(ctx.ThreadID, ctx.RunID)
+			close(ch)
+			return ch
+		},
+	}
+	handler := New(Config{
+		EventSource:  source,
+		Interceptors: []EventInterceptor{NewPIIRedactor(RedactionConfig{})},
+	})
+
+	body, _ := json.Marshal(RunAgentInput{ThreadID: "thread-1"})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	out := rr.Body.String()
+	assert.NotContains(t, out, "4111")
+	assert.Contains(t, out, "[REDACTED_CREDIT_CARD]")
+	assert.Less(t, strings.Index(out, "REDACTED"), strings.Index(out, "TEXT_MESSAGE_END"))
+}
@@ -0,0 +1,746 @@
+package aguigo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// Conversation is what an event stream adds up to: the messages, the state
+// and where the run stands
+type Conversation struct {
+	ThreadID string `json:"threadId,omitempty"`
+	RunID    string `json:"runId,omitempty"`
+	// Status is RunStateRunning, RunStateFinished or RunStateFailed; it is
+	// empty until the stream says how the run is doing
+	Status string `json:"status,omitempty"`
+	// Error and Code come from RUN_ERROR
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+	// Result comes from RUN_FINISHED
+	Result   any                   `json:"result,omitempty"`
+	Messages []ConversationMessage `json:"messages"`
+	State    any                   `json:"state,omitempty"`
+	Thinking []ThinkingBlock       `json:"thinking,omitempty"`
+}
+
+// ConversationMessage is a message rebuilt from TEXT_MESSAGE_*, TOOL_CALL_*
+// and MESSAGES_SNAPSHOT events
+type ConversationMessage struct {
+	ID      string `json:"id"`
+	Role    string `json:"role"`
+	Content string `json:"content,omitempty"`
+	Name    string `json:"name,omitempty"`
+	// ToolCalls are the calls an assistant message made
+	ToolCalls []events.ToolCall `json:"toolCalls,omitempty"`
+	// ToolCallID is the call a tool message answers
+	ToolCallID string `json:"toolCallId,omitempty"`
+}
+
+// PendingToolCalls returns the tool calls no tool message has answered yet,
+// such as the frontend tools a run asked the client to run
+func (c Conversation) PendingToolCalls() []events.ToolCall {
+	answered := make(map[string]bool)
+	for _, m := range c.Messages {
+		if m.ToolCallID != "" {
+			answered[m.ToolCallID] = true
+		}
+	}
+	var pending []events.ToolCall
+	for _, m := range c.Messages {
+		for _, call := range m.ToolCalls {
+			if !answered[call.ID] {
+				pending = append(pending, call)
+			}
+		}
+	}
+	return pending
+}
+
+// message converts m to the input form sent back to the agent
+func (m ConversationMessage) message() Message {
+	msg := Message{ID: m.ID, Role: m.Role, Name: m.Name, ToolCalls: m.ToolCalls, ToolCallID: m.ToolCallID}
+	if m.Content != "" {
+		msg.Content = []ContentPart{{Type: "text", Text: m.Content}}
+	}
+	return msg
+}
+
+// ThinkingBlock is the reasoning between THINKING_START and THINKING_END
+type ThinkingBlock struct {
+	Title   string `json:"title,omitempty"`
+	Content string `json:"content"`
+}
+
+// ReduceError describes an event a Reducer could not apply
+type ReduceError struct {
+	// Index is the position of the event among those given to the reducer
+	Index int
+	// EventType is the type of the event
+	EventType events.EventType
+	// Reason explains what was wrong with it
+	Reason string
+	// Err is the underlying error, such as a failed JSON Patch operation
+	Err error
+}
+
+func (e *ReduceError) Error() string {
+	msg := fmt.Sprintf("cannot apply event %d (%s): %s", e.Index, e.EventType, e.Reason)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ReduceError) Unwrap() error { return e.Err }
+
+// toolCallRef locates a tool call within its message
+type toolCallRef struct {
+	msg   *ConversationMessage
+	index int
+}
+
+// Reducer rebuilds a conversation from its events, as the AG-UI clients do.
+// Events that don't fit what came before, such as content for a message
+// that was never started, are rejected with a ReduceError and leave the
+// conversation unchanged. Ordering rules beyond that, like RUN_STARTED
+// coming first, are checked by SequenceValidator.
+type Reducer struct {
+	conv     Conversation
+	messages []*ConversationMessage
+	byID     map[string]*ConversationMessage
+	calls    map[string]toolCallRef
+
+	openMessages map[string]bool
+	openCalls    map[string]bool
+	chunkMessage string
+	chunkCall    string
+	thinking     *ThinkingBlock
+	thinkingText bool
+
+	index int
+}
+
+// NewReducer creates a reducer for an empty conversation
+func NewReducer() *Reducer {
+	return &Reducer{
+		byID:         make(map[string]*ConversationMessage),
+		calls:        make(map[string]toolCallRef),
+		openMessages: make(map[string]bool),
+		openCalls:    make(map[string]bool),
+	}
+}
+
+// Reduce applies evts to an empty conversation
+func Reduce(evts []events.Event) (Conversation, error) {
+	r := NewReducer()
+	for _, evt := range evts {
+		if err := r.Apply(evt); err != nil {
+			return r.Conversation(), err
+		}
+	}
+	return r.Conversation(), nil
+}
+
+// Seed adds the conversation history a run started from
+func (r *Reducer) Seed(messages []Message) {
+	for _, m := range messages {
+		msg := r.message(m.ID, m.Role)
+		msg.Name = m.Name
+		msg.ToolCallID = m.ToolCallID
+		for _, call := range m.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, call)
+			r.calls[call.ID] = toolCallRef{msg: msg, index: len(msg.ToolCalls) - 1}
+		}
+		for _, part := range m.Content {
+			if part.Type == "text" {
+				msg.Content += part.Text
+			}
+		}
+	}
+}
+
+// SeedState sets the state a run started from
+func (r *Reducer) SeedState(state any) {
+	r.conv.State = normalizeJSON(state)
+}
+
+// Apply updates the conversation with one event
+func (r *Reducer) Apply(evt events.Event) error {
+	defer func() { r.index++ }()
+
+	fail := func(format string, args ...any) error {
+		return &ReduceError{Index: r.index, EventType: evt.Type(), Reason: fmt.Sprintf(format, args...)}
+	}
+
+	switch e := evt.(type) {
+	case *events.RunStartedEvent:
+		r.conv.ThreadID, r.conv.RunID = e.ThreadIDValue, e.RunIDValue
+		r.conv.Status = RunStateRunning
+		r.conv.Error, r.conv.Code, r.conv.Result = "", "", nil
+	case *events.RunFinishedEvent:
+		r.conv.Status = RunStateFinished
+		r.conv.Result = e.Result
+	case *events.RunErrorEvent:
+		r.conv.Status = RunStateFailed
+		r.conv.Error = e.Message
+		if e.Code != nil {
+			r.conv.Code = *e.Code
+		}
+
+	case *events.TextMessageStartEvent:
+		if _, ok := r.byID[e.MessageID]; ok {
+			return fail("message %q already exists", e.MessageID)
+		}
+		role := "assistant"
+		if e.Role != nil {
+			role = *e.Role
+		}
+		r.message(e.MessageID, role)
+		r.openMessages[e.MessageID] = true
+	case *events.TextMessageContentEvent:
+		if !r.openMessages[e.MessageID] {
+			return fail("message %q is not open", e.MessageID)
+		}
+		r.byID[e.MessageID].Content += e.Delta
+	case *events.TextMessageEndEvent:
+		if !r.openMessages[e.MessageID] {
+			return fail("message %q is not open", e.MessageID)
+		}
+		delete(r.openMessages, e.MessageID)
+	case *events.TextMessageChunkEvent:
+		id := r.chunkMessage
+		if e.MessageID != nil {
+			id = *e.MessageID
+		}
+		if id == "" {
+			return fail("the first chunk of a message needs a message ID")
+		}
+		msg, ok := r.byID[id]
+		if !ok {
+			role := "assistant"
+			if e.Role != nil {
+				role = *e.Role
+			}
+			msg = r.message(id, role)
+		}
+		if e.Delta != nil {
+			msg.Content += *e.Delta
+		}
+		r.chunkMessage = id
+
+	case *events.ToolCallStartEvent:
+		if _, ok := r.calls[e.ToolCallID]; ok {
+			return fail("tool call %q already exists", e.ToolCallID)
+		}
+		parentID := e.ToolCallID
+		if e.ParentMessageID != nil {
+			parentID = *e.ParentMessageID
+		}
+		r.addToolCall(parentID, e.ToolCallID, e.ToolCallName)
+		r.openCalls[e.ToolCallID] = true
+	case *events.ToolCallArgsEvent:
+		if !r.openCalls[e.ToolCallID] {
+			return fail("tool call %q is not open", e.ToolCallID)
+		}
+		r.toolCall(e.ToolCallID).Function.Arguments += e.Delta
+	case *events.ToolCallEndEvent:
+		if !r.openCalls[e.ToolCallID] {
+			return fail("tool call %q is not open", e.ToolCallID)
+		}
+		delete(r.openCalls, e.ToolCallID)
+	case *events.ToolCallChunkEvent:
+		id := r.chunkCall
+		if e.ToolCallID != nil {
+			id = *e.ToolCallID
+		}
+		if id == "" {
+			return fail("the first chunk of a tool call needs a tool call ID")
+		}
+		if _, ok := r.calls[id]; !ok {
+			if e.ToolCallName == nil {
+				return fail("the first chunk of tool call %q needs a tool name", id)
+			}
+			parentID := id
+			if e.ParentMessageID != nil {
+				parentID = *e.ParentMessageID
+			}
+			r.addToolCall(parentID, id, *e.ToolCallName)
+		}
+		if e.Delta != nil {
+			r.toolCall(id).Function.Arguments += *e.Delta
+		}
+		r.chunkCall = id
+	case *events.ToolCallResultEvent:
+		// The call may have been made in an earlier run, so it need not be known
+		if _, ok := r.byID[e.MessageID]; ok {
+			return fail("message %q already exists", e.MessageID)
+		}
+		delete(r.openCalls, e.ToolCallID)
+		msg := r.message(e.MessageID, "tool")
+		msg.ToolCallID = e.ToolCallID
+		msg.Content = e.Content
+
+	case *events.MessagesSnapshotEvent:
+		r.replaceMessages(e.Messages)
+
+	case *events.StateSnapshotEvent:
+		r.conv.State = normalizeJSON(e.Snapshot)
+	case *events.StateDeltaEvent:
+		// Like the AG-UI clients, a run without state starts from an empty object
+		doc := r.conv.State
+		if doc == nil {
+			doc = map[string]any{}
+		}
+		state, err := applyJSONPatch(doc, e.Delta)
+		if err != nil {
+			return &ReduceError{Index: r.index, EventType: evt.Type(), Reason: "state delta does not apply", Err: err}
+		}
+		r.conv.State = state
+
+	case *events.ThinkingStartEvent:
+		if r.thinking != nil {
+			return fail("already thinking")
+		}
+		r.thinking = &ThinkingBlock{}
+		if e.Title != nil {
+			r.thinking.Title = *e.Title
+		}
+	case *events.ThinkingTextMessageStartEvent:
+		if r.thinking == nil {
+			return fail("thinking message outside THINKING_START/THINKING_END")
+		}
+		if r.thinkingText {
+			return fail("a thinking message is already open")
+		}
+		r.thinkingText = true
+	case *events.ThinkingTextMessageContentEvent:
+		if !r.thinkingText {
+			return fail("no thinking message is open")
+		}
+		r.thinking.Content += e.Delta
+	case *events.ThinkingTextMessageEndEvent:
+		if !r.thinkingText {
+			return fail("no thinking message is open")
+		}
+		r.thinkingText = false
+	case *events.ThinkingEndEvent:
+		if r.thinking == nil {
+			return fail("not thinking")
+		}
+		r.conv.Thinking = append(r.conv.Thinking, *r.thinking)
+		r.thinking, r.thinkingText = nil, false
+	}
+	return nil
+}
+
+// Conversation returns a copy of the conversation so far
+func (r *Reducer) Conversation() Conversation {
+	conv := r.conv
+	conv.Messages = make([]ConversationMessage, 0, len(r.messages))
+	for _, msg := range r.messages {
+		m := *msg
+		m.ToolCalls = append([]events.ToolCall(nil), msg.ToolCalls...)
+		conv.Messages = append(conv.Messages, m)
+	}
+	conv.State = normalizeJSON(r.conv.State)
+	conv.Thinking = append([]ThinkingBlock(nil), r.conv.Thinking...)
+	return conv
+}
+
+// Messages returns the messages in their protocol form, as carried by
+// MESSAGES_SNAPSHOT. Messages that only make tool calls have no content.
+func (r *Reducer) Messages() []events.Message {
+	messages := make([]events.Message, 0, len(r.messages))
+	for _, msg := range r.messages {
+		messages = append(messages, protocolMessage(msg, msg.ToolCalls))
+	}
+	return messages
+}
+
+// resume splits the conversation for a client joining mid-run: the
+// messages to snapshot, without the messages and tool calls still open,
+// and the events that start those again with what they hold so far
+func (r *Reducer) resume() ([]events.Message, []events.Event) {
+	messages := make([]events.Message, 0, len(r.messages))
+	var reopen []events.Event
+	for _, msg := range r.messages {
+		open := r.openMessages[msg.ID]
+		if open {
+			reopen = append(reopen, events.NewTextMessageStartEvent(msg.ID, events.WithRole(msg.Role)))
+			if msg.Content != "" {
+				reopen = append(reopen, events.NewTextMessageContentEvent(msg.ID, msg.Content))
+			}
+		}
+
+		// Calls of an open message are replayed with it, closed or not
+		var calls []events.ToolCall
+		for _, call := range msg.ToolCalls {
+			if !open && !r.openCalls[call.ID] {
+				calls = append(calls, call)
+				continue
+			}
+			var opts []events.ToolCallStartOption
+			if msg.ID != call.ID {
+				opts = append(opts, events.WithParentMessageID(msg.ID))
+			}
+			reopen = append(reopen, events.NewToolCallStartEvent(call.ID, call.Function.Name, opts...))
+			if call.Function.Arguments != "" {
+				reopen = append(reopen, events.NewToolCallArgsEvent(call.ID, call.Function.Arguments))
+			}
+			if !r.openCalls[call.ID] {
+				reopen = append(reopen, events.NewToolCallEndEvent(call.ID))
+			}
+		}
+
+		// A message left with nothing but open calls is created again by them
+		if open || (msg.Content == "" && len(calls) == 0 && len(msg.ToolCalls) > 0) {
+			continue
+		}
+		messages = append(messages, protocolMessage(msg, calls))
+	}
+
+	if r.thinking != nil {
+		start := events.NewThinkingStartEvent()
+		if r.thinking.Title != "" {
+			start = start.WithTitle(r.thinking.Title)
+		}
+		reopen = append(reopen, start)
+		if r.thinkingText {
+			reopen = append(reopen, events.NewThinkingTextMessageStartEvent())
+			if r.thinking.Content != "" {
+				reopen = append(reopen, events.NewThinkingTextMessageContentEvent(r.thinking.Content))
+			}
+		}
+	}
+	return messages, reopen
+}
+
+// closing returns the events that end whatever is open
+func (r *Reducer) closing() []events.Event {
+	var out []events.Event
+	if r.thinkingText {
+		out = append(out, events.NewThinkingTextMessageEndEvent())
+	}
+	if r.thinking != nil {
+		out = append(out, events.NewThinkingEndEvent())
+	}
+	for _, msg := range r.messages {
+		if r.openMessages[msg.ID] {
+			out = append(out, events.NewTextMessageEndEvent(msg.ID))
+		}
+	}
+	for _, msg := range r.messages {
+		for _, call := range msg.ToolCalls {
+			if r.openCalls[call.ID] {
+				out = append(out, events.NewToolCallEndEvent(call.ID))
+			}
+		}
+	}
+	return out
+}
+
+// protocolMessage converts msg with the given tool calls
+func protocolMessage(msg *ConversationMessage, calls []events.ToolCall) events.Message {
+	m := events.Message{ID: msg.ID, Role: msg.Role}
+	if msg.Content != "" || len(calls) == 0 {
+		content := msg.Content
+		m.Content = &content
+	}
+	if msg.Name != "" {
+		name := msg.Name
+		m.Name = &name
+	}
+	if msg.ToolCallID != "" {
+		toolCallID := msg.ToolCallID
+		m.ToolCallID = &toolCallID
+	}
+	m.ToolCalls = append(m.ToolCalls, calls...)
+	return m
+}
+
+// State returns the current state, or nil if there is none
+func (r *Reducer) State() any {
+	return normalizeJSON(r.conv.State)
+}
+
+// message returns the message with id, adding it if it is new
+func (r *Reducer) message(id, role string) *ConversationMessage {
+	if msg, ok := r.byID[id]; ok {
+		return msg
+	}
+	msg := &ConversationMessage{ID: id, Role: role}
+	r.messages = append(r.messages, msg)
+	r.byID[id] = msg
+	return msg
+}
+
+// addToolCall adds a call to the assistant message parentID
+func (r *Reducer) addToolCall(parentID, id, name string) {
+	msg := r.message(parentID, "assistant")
+	msg.ToolCalls = append(msg.ToolCalls, events.ToolCall{ID: id, Type: "function", Function: events.Function{Name: name}})
+	r.calls[id] = toolCallRef{msg: msg, index: len(msg.ToolCalls) - 1}
+}
+
+func (r *Reducer) toolCall(id string) *events.ToolCall {
+	ref := r.calls[id]
+	return &ref.msg.ToolCalls[ref.index]
+}
+
+// replaceMessages swaps the conversation for a MESSAGES_SNAPSHOT. Messages
+// and tool calls that were open stay open if the snapshot still has them.
+func (r *Reducer) replaceMessages(snapshot []events.Message) {
+	r.messages = nil
+	r.byID = make(map[string]*ConversationMessage)
+	r.calls = make(map[string]toolCallRef)
+
+	for _, m := range snapshot {
+		msg := r.message(m.ID, m.Role)
+		if m.Content != nil {
+			msg.Content = *m.Content
+		}
+		if m.Name != nil {
+			msg.Name = *m.Name
+		}
+		if m.ToolCallID != nil {
+			msg.ToolCallID = *m.ToolCallID
+		}
+		for _, call := range m.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, call)
+			r.calls[call.ID] = toolCallRef{msg: msg, index: len(msg.ToolCalls) - 1}
+		}
+	}
+
+	for id := range r.openMessages {
+		if _, ok := r.byID[id]; !ok {
+			delete(r.openMessages, id)
+		}
+	}
+	for id := range r.openCalls {
+		if _, ok := r.calls[id]; !ok {
+			delete(r.openCalls, id)
+		}
+	}
+}
+
+// normalizeJSON converts v to its generic JSON form (maps, slices and
+// scalars) so patches can be applied to it
+func normalizeJSON(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// applyJSONPatch applies RFC 6902 operations to a generic JSON document and
+// returns the result. doc is not modified.
+func applyJSONPatch(doc any, ops []events.JSONPatchOperation) (any, error) {
+	doc = normalizeJSON(doc)
+
+	for i, op := range ops {
+		var err error
+		value := normalizeJSON(op.Value)
+
+		switch op.Op {
+		case "add":
+			doc, err = patchAdd(doc, op.Path, value)
+		case "remove":
+			doc, _, err = patchRemove(doc, op.Path)
+		case "replace":
+			if doc, _, err = patchRemove(doc, op.Path); err == nil {
+				doc, err = patchAdd(doc, op.Path, value)
+			}
+		case "move":
+			var moved any
+			if doc, moved, err = patchRemove(doc, op.From); err == nil {
+				doc, err = patchAdd(doc, op.Path, moved)
+			}
+		case "copy":
+			var copied any
+			if copied, err = patchGet(doc, op.From); err == nil {
+				doc, err = patchAdd(doc, op.Path, normalizeJSON(copied))
+			}
+		case "test":
+			var current any
+			if current, err = patchGet(doc, op.Path); err == nil && !jsonEqual(current, value) {
+				err = fmt.Errorf("test failed at %q", op.Path)
+			}
+		default:
+			err = fmt.Errorf("unknown op %q", op.Op)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("json patch operation %d: %w", i, err)
+		}
+	}
+
+	return doc, nil
+}
+
+// splitPointer splits a JSON pointer into unescaped reference tokens
+func splitPointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses an array index token; "-" means one past the end
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return length, nil
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || idx > length || (!allowEnd && idx == length) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return idx, nil
+}
+
+func patchGet(doc any, path string) (any, error) {
+	tokens, err := splitPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	cur := doc
+	for _, t := range tokens {
+		switch node := cur.(type) {
+		case map[string]any:
+			v, ok := node[t]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", path)
+			}
+			cur = v
+		case []any:
+			idx, err := arrayIndex(t, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			cur = node[idx]
+		default:
+			return nil, fmt.Errorf("path %q not found", path)
+		}
+	}
+	return cur, nil
+}
+
+// patchAdd sets the value at path, inserting into arrays
+func patchAdd(doc any, path string, value any) (any, error) {
+	tokens, err := splitPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	return patchUpdate(doc, tokens, func(parent any, last string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[last] = value
+			return node, nil
+		case []any:
+			idx, err := arrayIndex(last, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[idx+1:], node[idx:])
+			node[idx] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("cannot add to %q", path)
+		}
+	}, value)
+}
+
+// patchRemove deletes the value at path and returns it
+func patchRemove(doc any, path string) (any, any, error) {
+	tokens, err := splitPointer(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+
+	var removed any
+	doc, err = patchUpdate(doc, tokens, func(parent any, last string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			v, ok := node[last]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", path)
+			}
+			removed = v
+			delete(node, last)
+			return node, nil
+		case []any:
+			idx, err := arrayIndex(last, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[idx]
+			return append(node[:idx], node[idx+1:]...), nil
+		default:
+			return nil, fmt.Errorf("path %q not found", path)
+		}
+	}, nil)
+	return doc, removed, err
+}
+
+// patchUpdate walks to the parent of the last token, lets update change it
+// and writes the (possibly reallocated) parent back. An empty path replaces
+// the whole document with root.
+func patchUpdate(doc any, tokens []string, update func(parent any, last string) (any, error), root any) (any, error) {
+	if len(tokens) == 0 {
+		return root, nil
+	}
+	if len(tokens) == 1 {
+		return update(doc, tokens[0])
+	}
+
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("path segment %q not found", tokens[0])
+		}
+		updated, err := patchUpdate(child, tokens[1:], update, root)
+		if err != nil {
+			return nil, err
+		}
+		node[tokens[0]] = updated
+		return node, nil
+	case []any:
+		idx, err := arrayIndex(tokens[0], len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := patchUpdate(node[idx], tokens[1:], update, root)
+		if err != nil {
+			return nil, err
+		}
+		node[idx] = updated
+		return node, nil
+	default:
+		return nil, fmt.Errorf("path segment %q not found", tokens[0])
+	}
+}
+
+func jsonEqual(a, b any) bool {
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(da) == string(db)
+}
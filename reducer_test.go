@@ -0,0 +1,247 @@
+package aguigo
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReduce(t *testing.T) {
+	conv, err := Reduce([]events.Event{
+		events.NewRunStartedEvent("thread-1", "run-1"),
+		events.NewThinkingStartEvent().WithTitle("Planning"),
+		events.NewThinkingTextMessageStartEvent(),
+		events.NewThinkingTextMessageContentEvent("Look it "),
+		events.NewThinkingTextMessageContentEvent("up"),
+		events.NewThinkingTextMessageEndEvent(),
+		events.NewThinkingEndEvent(),
+		events.NewTextMessageStartEvent("m1", events.WithRole("assistant")),
+		events.NewTextMessageContentEvent("m1", "Let me "),
+		events.NewTextMessageContentEvent("m1", "check"),
+		events.NewTextMessageEndEvent("m1"),
+		events.NewToolCallStartEvent("call-1", "get_weather", events.WithParentMessageID("m1")),
+		events.NewToolCallArgsEvent("call-1", `{"city":`),
+		events.NewToolCallArgsEvent("call-1", `"Paris"}`),
+		events.NewToolCallEndEvent("call-1"),
+		events.NewToolCallResultEvent("m2", "call-1", "Sunny"),
+		events.NewStateSnapshotEvent(map[string]any{"todos": []string{"a"}}),
+		events.NewStateDeltaEvent([]events.JSONPatchOperation{{Op: "add", Path: "/todos/-", Value: "b"}}),
+		events.NewRunFinishedEvent("thread-1", "run-1"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "thread-1", conv.ThreadID)
+	assert.Equal(t, "run-1", conv.RunID)
+	assert.Equal(t, RunStateFinished, conv.Status)
+	assert.Equal(t, []ThinkingBlock{{Title: "Planning", Content: "Look it up"}}, conv.Thinking)
+	assert.Equal(t, map[string]any{"todos": []any{"a", "b"}}, conv.State)
+	assert.Equal(t, []ConversationMessage{
+		{
+			ID: "m1", Role: "assistant", Content: "Let me check",
+			ToolCalls: []events.ToolCall{{ID: "call-1", Type: "function", Function: events.Function{Name: "get_weather", Arguments: `{"city":"Paris"}`}}},
+		},
+		{ID: "m2", Role: "tool", Content: "Sunny", ToolCallID: "call-1"},
+	}, conv.Messages)
+}
+
+func TestReducer_Chunks(t *testing.T) {
+	conv, err := Reduce([]events.Event{
+		events.NewTextMessageChunkEvent(nil, nil, nil).WithChunkMessageID("m1").WithChunkRole("assistant").WithChunkDelta("Hel"),
+		events.NewTextMessageChunkEvent(nil, nil, nil).WithChunkDelta("lo"),
+		events.NewToolCallChunkEvent().WithToolCallChunkID("call-1").WithToolCallChunkName("search").WithToolCallChunkParentMessageID("m1").WithToolCallChunkDelta(`{"q":`),
+		events.NewToolCallChunkEvent().WithToolCallChunkDelta(`"go"}`),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, conv.Messages, 1)
+	assert.Equal(t, "Hello", conv.Messages[0].Content)
+	require.Len(t, conv.Messages[0].ToolCalls, 1)
+	assert.Equal(t, `{"q":"go"}`, conv.Messages[0].ToolCalls[0].Function.Arguments)
+}
+
+func TestReducer_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []events.Event
+		reason string
+	}{
+		{
+			name:   "content before start",
+			events: []events.Event{events.NewTextMessageContentEvent("m1", "hi")},
+			reason: `message "m1" is not open`,
+		},
+		{
+			name: "content after end",
+			events: []events.Event{
+				events.NewTextMessageStartEvent("m1"),
+				events.NewTextMessageEndEvent("m1"),
+				events.NewTextMessageContentEvent("m1", "late"),
+			},
+			reason: `message "m1" is not open`,
+		},
+		{
+			name: "duplicate message",
+			events: []events.Event{
+				events.NewTextMessageStartEvent("m1"),
+				events.NewTextMessageStartEvent("m1"),
+			},
+			reason: `message "m1" already exists`,
+		},
+		{
+			name:   "args for unknown tool call",
+			events: []events.Event{events.NewToolCallArgsEvent("call-1", "{}")},
+			reason: `tool call "call-1" is not open`,
+		},
+		{
+			name: "duplicate tool call",
+			events: []events.Event{
+				events.NewToolCallStartEvent("call-1", "search"),
+				events.NewToolCallStartEvent("call-1", "search"),
+			},
+			reason: `tool call "call-1" already exists`,
+		},
+		{
+			name:   "chunk without a message ID",
+			events: []events.Event{events.NewTextMessageChunkEvent(nil, nil, nil).WithChunkDelta("hi")},
+			reason: "the first chunk of a message needs a message ID",
+		},
+		{
+			name:   "thinking content outside a thinking message",
+			events: []events.Event{events.NewThinkingStartEvent(), events.NewThinkingTextMessageContentEvent("hmm")},
+			reason: "no thinking message is open",
+		},
+		{
+			name:   "thinking end without start",
+			events: []events.Event{events.NewThinkingEndEvent()},
+			reason: "not thinking",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Reduce(tt.events)
+			var reduceErr *ReduceError
+			require.ErrorAs(t, err, &reduceErr)
+			assert.Equal(t, len(tt.events)-1, reduceErr.Index)
+			assert.Equal(t, tt.reason, reduceErr.Reason)
+		})
+	}
+
+	t.Run("a failed state delta leaves the state unchanged", func(t *testing.T) {
+		r := NewReducer()
+		require.NoError(t, r.Apply(events.NewStateSnapshotEvent(map[string]any{"count": 1})))
+
+		err := r.Apply(events.NewStateDeltaEvent([]events.JSONPatchOperation{
+			{Op: "replace", Path: "/count", Value: 2},
+			{Op: "remove", Path: "/missing"},
+		}))
+		var reduceErr *ReduceError
+		require.ErrorAs(t, err, &reduceErr)
+		assert.NotNil(t, errors.Unwrap(err))
+		assert.Contains(t, err.Error(), "state delta does not apply")
+		assert.Equal(t, map[string]any{"count": float64(1)}, r.State())
+	})
+}
+
+func TestReducer_MessagesSnapshot(t *testing.T) {
+	r := NewReducer()
+	r.Seed([]Message{{ID: "u1", Role: "user", Content: []ContentPart{{Type: "text", Text: "Hi"}}}})
+	require.NoError(t, r.Apply(events.NewTextMessageStartEvent("m1")))
+
+	content := "Hi"
+	require.NoError(t, r.Apply(events.NewMessagesSnapshotEvent([]events.Message{
+		{ID: "u1", Role: "user", Content: &content},
+		{ID: "m1", Role: "assistant"},
+	})))
+
+	// m1 was open and is still in the snapshot, so it stays open
+	require.NoError(t, r.Apply(events.NewTextMessageContentEvent("m1", "Hello")))
+
+	messages := r.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "Hi", *messages[0].Content)
+	assert.Equal(t, "Hello", *messages[1].Content)
+
+	t.Run("conversations are copies", func(t *testing.T) {
+		conv := r.Conversation()
+		conv.Messages[0].Content = "changed"
+		assert.Equal(t, "Hi", r.Conversation().Messages[0].Content)
+	})
+
+	t.Run("tool call only messages have no content", func(t *testing.T) {
+		require.NoError(t, r.Apply(events.NewToolCallStartEvent("call-1", "search")))
+		data, err := json.Marshal(r.Messages()[2])
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), `"content"`)
+	})
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	doc := map[string]any{
+		"name":  "draft",
+		"items": []any{"a", "b"},
+		"meta":  map[string]any{"a/b": 1},
+	}
+
+	tests := []struct {
+		name string
+		ops  []events.JSONPatchOperation
+		want any
+	}{
+		{
+			name: "add and replace",
+			ops: []events.JSONPatchOperation{
+				{Op: "add", Path: "/items/1", Value: "x"},
+				{Op: "replace", Path: "/name", Value: "final"},
+			},
+			want: map[string]any{"name": "final", "items": []any{"a", "x", "b"}, "meta": map[string]any{"a/b": float64(1)}},
+		},
+		{
+			name: "remove with escaped pointer",
+			ops:  []events.JSONPatchOperation{{Op: "remove", Path: "/meta/a~1b"}},
+			want: map[string]any{"name": "draft", "items": []any{"a", "b"}, "meta": map[string]any{}},
+		},
+		{
+			name: "move and copy",
+			ops: []events.JSONPatchOperation{
+				{Op: "move", From: "/name", Path: "/title"},
+				{Op: "copy", From: "/items/0", Path: "/first"},
+				{Op: "test", Path: "/first", Value: "a"},
+			},
+			want: map[string]any{"title": "draft", "first": "a", "items": []any{"a", "b"}, "meta": map[string]any{"a/b": float64(1)}},
+		},
+		{
+			name: "replace the whole document",
+			ops:  []events.JSONPatchOperation{{Op: "replace", Path: "", Value: []string{"x"}}},
+			want: []any{"x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyJSONPatch(doc, tt.ops)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		for _, ops := range [][]events.JSONPatchOperation{
+			{{Op: "remove", Path: "/missing"}},
+			{{Op: "add", Path: "/items/5", Value: 1}},
+			{{Op: "test", Path: "/name", Value: "other"}},
+			{{Op: "add", Path: "name", Value: 1}},
+			{{Op: "frobnicate", Path: "/name"}},
+		} {
+			_, err := applyJSONPatch(doc, ops)
+			assert.Error(t, err, ops[0].Op)
+		}
+	})
+
+	// The input document is left untouched
+	assert.Equal(t, "draft", doc["name"])
+}
@@ -0,0 +1,242 @@
+package aguigo
+
+import (
+	"fmt"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// PartKind identifies which field of a genai.Part a PartConverter handles
+type PartKind string
+
+// Built-in part kinds, in the order they are converted within a part
+const (
+	PartKindThought             PartKind = "thought"
+	PartKindText                PartKind = "text"
+	PartKindFunctionCall        PartKind = "function_call"
+	PartKindFunctionResponse    PartKind = "function_response"
+	PartKindExecutableCode      PartKind = "executable_code"
+	PartKindCodeExecutionResult PartKind = "code_execution_result"
+	PartKindInlineData          PartKind = "inline_data"
+	PartKindFileData            PartKind = "file_data"
+)
+
+// ActionKind identifies which field of session.EventActions an ActionConverter handles
+type ActionKind string
+
+// Built-in action kinds, in the order they are converted
+const (
+	ActionKindStateDelta    ActionKind = "state_delta"
+	ActionKindArtifactDelta ActionKind = "artifact_delta"
+	ActionKindTransfer      ActionKind = "transfer_to_agent"
+	ActionKindEscalate      ActionKind = "escalate"
+)
+
+// PartConverter converts one part of an ADK event into AG-UI events.
+// The converter is passed in so implementations can open or close text
+// messages via StartTextMessage/EndTextMessage and read the run IDs and options.
+type PartConverter func(c *ADKConverter, adkEvent *session.Event, part *genai.Part) []events.Event
+
+// ActionConverter converts the actions of an ADK event into AG-UI events
+type ActionConverter func(c *ADKConverter, adkEvent *session.Event, actions *session.EventActions) []events.Event
+
+type partEntry struct {
+	kind    PartKind
+	match   func(part *genai.Part) bool
+	convert PartConverter
+}
+
+type actionEntry struct {
+	kind    ActionKind
+	match   func(actions *session.EventActions) bool
+	convert ActionConverter
+}
+
+// ConverterRegistry holds the part and action converters used by ADKConverter.
+// Configure it before handing it to converters; it is not safe to modify
+// while events are being converted.
+type ConverterRegistry struct {
+	parts   []partEntry
+	actions []actionEntry
+}
+
+// defaultRegistry is shared by converters that are not given a registry
+var defaultRegistry = NewConverterRegistry()
+
+// NewConverterRegistry creates a registry populated with the built-in converters
+func NewConverterRegistry() *ConverterRegistry {
+	return &ConverterRegistry{
+		parts: []partEntry{
+			{PartKindThought, func(p *genai.Part) bool { return p.Thought && p.Text != "" }, convertThoughtPart},
+			{PartKindText, func(p *genai.Part) bool { return !p.Thought && p.Text != "" }, convertTextPart},
+			{PartKindFunctionCall, func(p *genai.Part) bool { return p.FunctionCall != nil }, convertFunctionCallPart},
+			{PartKindFunctionResponse, func(p *genai.Part) bool { return p.FunctionResponse != nil }, convertFunctionResponsePart},
+			{PartKindExecutableCode, func(p *genai.Part) bool { return p.ExecutableCode != nil }, convertExecutableCodePart},
+			{PartKindCodeExecutionResult, func(p *genai.Part) bool { return p.CodeExecutionResult != nil }, convertCodeExecutionResultPart},
+			{PartKindInlineData, func(p *genai.Part) bool { return p.InlineData != nil }, convertInlineDataPart},
+			{PartKindFileData, func(p *genai.Part) bool { return p.FileData != nil }, convertFileDataPart},
+		},
+		actions: []actionEntry{
+			{ActionKindStateDelta, func(a *session.EventActions) bool { return len(a.StateDelta) > 0 }, convertStateDeltaAction},
+			{ActionKindArtifactDelta, func(a *session.EventActions) bool { return len(a.ArtifactDelta) > 0 }, convertArtifactDeltaAction},
+			{ActionKindTransfer, func(a *session.EventActions) bool { return a.TransferToAgent != "" }, convertTransferAction},
+			{ActionKindEscalate, func(a *session.EventActions) bool { return a.Escalate }, convertEscalateAction},
+		},
+	}
+}
+
+// RegisterPart replaces the converter for a built-in or previously added part kind.
+// A nil converter drops parts of that kind. It panics if the kind is unknown.
+func (r *ConverterRegistry) RegisterPart(kind PartKind, convert PartConverter) {
+	for i := range r.parts {
+		if r.parts[i].kind == kind {
+			r.parts[i].convert = convert
+			return
+		}
+	}
+	panic(fmt.Sprintf("aguigo: RegisterPart: unknown part kind %q", kind))
+}
+
+// AddPart adds a converter for a new part kind, run after the built-in kinds
+// for every part the match function accepts. Adding an existing kind replaces it.
+func (r *ConverterRegistry) AddPart(kind PartKind, match func(part *genai.Part) bool, convert PartConverter) {
+	for i := range r.parts {
+		if r.parts[i].kind == kind {
+			r.parts[i].match = match
+			r.parts[i].convert = convert
+			return
+		}
+	}
+	r.parts = append(r.parts, partEntry{kind: kind, match: match, convert: convert})
+}
+
+// RegisterAction replaces the converter for a built-in or previously added action kind.
+// A nil converter drops actions of that kind. It panics if the kind is unknown.
+func (r *ConverterRegistry) RegisterAction(kind ActionKind, convert ActionConverter) {
+	for i := range r.actions {
+		if r.actions[i].kind == kind {
+			r.actions[i].convert = convert
+			return
+		}
+	}
+	panic(fmt.Sprintf("aguigo: RegisterAction: unknown action kind %q", kind))
+}
+
+// AddAction adds a converter for a new action kind, run after the built-in kinds
+// whenever the match function accepts the event actions. Adding an existing kind replaces it.
+func (r *ConverterRegistry) AddAction(kind ActionKind, match func(actions *session.EventActions) bool, convert ActionConverter) {
+	for i := range r.actions {
+		if r.actions[i].kind == kind {
+			r.actions[i].match = match
+			r.actions[i].convert = convert
+			return
+		}
+	}
+	r.actions = append(r.actions, actionEntry{kind: kind, match: match, convert: convert})
+}
+
+// BuiltinPartConverter returns the built-in converter for a part kind, or nil
+// if the kind is not built in. Custom converters call it to fall back to the
+// default handling.
+func BuiltinPartConverter(kind PartKind) PartConverter {
+	for _, entry := range defaultRegistry.parts {
+		if entry.kind == kind {
+			return entry.convert
+		}
+	}
+	return nil
+}
+
+// BuiltinActionConverter returns the built-in converter for an action kind,
+// or nil if the kind is not built in
+func BuiltinActionConverter(kind ActionKind) ActionConverter {
+	for _, entry := range defaultRegistry.actions {
+		if entry.kind == kind {
+			return entry.convert
+		}
+	}
+	return nil
+}
+
+// convertPart runs every matching converter for a single part. Thought parts
+// are exclusive: their text is never also emitted as a regular message.
+func (r *ConverterRegistry) convertPart(c *ADKConverter, adkEvent *session.Event, part *genai.Part) []events.Event {
+	var result []events.Event
+	for _, entry := range r.parts {
+		if !entry.match(part) {
+			continue
+		}
+		if entry.convert != nil {
+			result = append(result, entry.convert(c, adkEvent, part)...)
+		}
+		if entry.kind == PartKindThought {
+			break
+		}
+	}
+	return result
+}
+
+// convertActions runs every matching action converter
+func (r *ConverterRegistry) convertActions(c *ADKConverter, adkEvent *session.Event, actions *session.EventActions) []events.Event {
+	var result []events.Event
+	for _, entry := range r.actions {
+		if entry.convert != nil && entry.match(actions) {
+			result = append(result, entry.convert(c, adkEvent, actions)...)
+		}
+	}
+	return result
+}
+
+// Built-in part converters
+
+func convertThoughtPart(c *ADKConverter, adkEvent *session.Event, part *genai.Part) []events.Event {
+	return c.handleThought(adkEvent, part.Text)
+}
+
+func convertTextPart(c *ADKConverter, adkEvent *session.Event, part *genai.Part) []events.Event {
+	return c.handleTextPart(adkEvent, part.Text)
+}
+
+func convertFunctionCallPart(c *ADKConverter, _ *session.Event, part *genai.Part) []events.Event {
+	return c.handleFunctionCall(part.FunctionCall)
+}
+
+func convertFunctionResponsePart(c *ADKConverter, _ *session.Event, part *genai.Part) []events.Event {
+	return c.handleFunctionResponse(part.FunctionResponse)
+}
+
+func convertExecutableCodePart(c *ADKConverter, _ *session.Event, part *genai.Part) []events.Event {
+	return c.handleExecutableCode(part.ExecutableCode)
+}
+
+func convertCodeExecutionResultPart(c *ADKConverter, _ *session.Event, part *genai.Part) []events.Event {
+	return c.handleCodeExecutionResult(part.CodeExecutionResult)
+}
+
+func convertInlineDataPart(c *ADKConverter, _ *session.Event, part *genai.Part) []events.Event {
+	return c.handleInlineData(part.InlineData)
+}
+
+func convertFileDataPart(c *ADKConverter, _ *session.Event, part *genai.Part) []events.Event {
+	return c.handleFileData(part.FileData)
+}
+
+// Built-in action converters
+
+func convertStateDeltaAction(c *ADKConverter, _ *session.Event, actions *session.EventActions) []events.Event {
+	return c.handleStateDelta(actions.StateDelta)
+}
+
+func convertArtifactDeltaAction(c *ADKConverter, _ *session.Event, actions *session.EventActions) []events.Event {
+	return c.handleArtifactDelta(actions.ArtifactDelta)
+}
+
+func convertTransferAction(c *ADKConverter, _ *session.Event, actions *session.EventActions) []events.Event {
+	return c.handleTransfer(actions.TransferToAgent)
+}
+
+func convertEscalateAction(c *ADKConverter, _ *session.Event, _ *session.EventActions) []events.Event {
+	return c.handleEscalation()
+}
@@ -0,0 +1,181 @@
+package aguigo
+
+import (
+	"testing"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+func TestConverterRegistry_RegisterPart(t *testing.T) {
+	t.Run("overrides a built-in part converter", func(t *testing.T) {
+		registry := NewConverterRegistry()
+		registry.RegisterPart(PartKindFunctionResponse, func(c *ADKConverter, _ *session.Event, part *genai.Part) []events.Event {
+			evts := c.StartTextMessage("assistant")
+			evts = append(evts, events.NewTextMessageContentEvent(c.CurrentMessageID(), "tool "+part.FunctionResponse.Name+" done"))
+			return evts
+		})
+		conv := NewADKConverter("thread-1", "run-1", WithConverterRegistry(registry))
+
+		adkEvent := &session.Event{
+			Author: "tool",
+			LLMResponse: model.LLMResponse{
+				Content: &genai.Content{
+					Parts: []*genai.Part{
+						{FunctionResponse: &genai.FunctionResponse{ID: "call-1", Name: "search"}},
+					},
+				},
+			},
+		}
+
+		evts := conv.ConvertEvent(adkEvent)
+
+		require.Len(t, evts, 2)
+		assert.Equal(t, events.EventTypeTextMessageStart, evts[0].Type())
+		content, ok := evts[1].(*events.TextMessageContentEvent)
+		require.True(t, ok)
+		assert.Equal(t, "tool search done", content.Delta)
+		assert.True(t, conv.IsMessageStarted())
+
+		// The built-in end-of-run handling closes the message opened by the custom converter
+		finish := conv.FinishRun()
+		require.Len(t, finish, 2)
+		assert.Equal(t, events.EventTypeTextMessageEnd, finish[0].Type())
+	})
+
+	t.Run("nil converter drops the part kind", func(t *testing.T) {
+		registry := NewConverterRegistry()
+		registry.RegisterPart(PartKindInlineData, nil)
+		conv := NewADKConverter("thread-1", "run-1", WithConverterRegistry(registry))
+
+		adkEvent := &session.Event{
+			LLMResponse: model.LLMResponse{
+				Content: &genai.Content{
+					Parts: []*genai.Part{
+						{InlineData: &genai.Blob{MIMEType: "image/png", Data: []byte{1, 2, 3}}},
+					},
+				},
+			},
+		}
+
+		assert.Empty(t, conv.ConvertEvent(adkEvent))
+	})
+
+	t.Run("does not affect the default registry", func(t *testing.T) {
+		registry := NewConverterRegistry()
+		registry.RegisterPart(PartKindText, nil)
+
+		conv := NewADKConverter("thread-1", "run-1")
+		adkEvent := &session.Event{
+			LLMResponse: model.LLMResponse{
+				Content: &genai.Content{Parts: []*genai.Part{{Text: "hi"}}},
+			},
+		}
+
+		assert.Len(t, conv.ConvertEvent(adkEvent), 2)
+	})
+
+	t.Run("falls back to the built-in converter", func(t *testing.T) {
+		builtin := BuiltinPartConverter(PartKindInlineData)
+		require.NotNil(t, builtin)
+
+		registry := NewConverterRegistry()
+		registry.RegisterPart(PartKindInlineData, func(c *ADKConverter, adkEvent *session.Event, part *genai.Part) []events.Event {
+			if part.InlineData.MIMEType == "image/png" {
+				return []events.Event{events.NewCustomEvent("image")}
+			}
+			return builtin(c, adkEvent, part)
+		})
+		conv := NewADKConverter("thread-1", "run-1", WithConverterRegistry(registry))
+
+		adkEvent := &session.Event{
+			LLMResponse: model.LLMResponse{
+				Content: &genai.Content{
+					Parts: []*genai.Part{
+						{InlineData: &genai.Blob{MIMEType: "image/png"}},
+						{InlineData: &genai.Blob{MIMEType: "audio/wav"}},
+					},
+				},
+			},
+		}
+
+		evts := conv.ConvertEvent(adkEvent)
+		require.Len(t, evts, 2)
+		assert.Equal(t, "image", evts[0].(*events.CustomEvent).Name)
+		assert.Equal(t, "inline_data", evts[1].(*events.CustomEvent).Name)
+	})
+
+	t.Run("unknown kind panics", func(t *testing.T) {
+		registry := NewConverterRegistry()
+		assert.PanicsWithValue(t, `aguigo: RegisterPart: unknown part kind "txt"`, func() {
+			registry.RegisterPart("txt", nil)
+		})
+		assert.Nil(t, BuiltinPartConverter("txt"))
+	})
+}
+
+func TestConverterRegistry_AddPart(t *testing.T) {
+	registry := NewConverterRegistry()
+	registry.AddPart("video_metadata",
+		func(p *genai.Part) bool { return p.VideoMetadata != nil },
+		func(c *ADKConverter, _ *session.Event, part *genai.Part) []events.Event {
+			return []events.Event{events.NewCustomEvent("video_metadata", events.WithValue(part.VideoMetadata.FPS))}
+		},
+	)
+	conv := NewADKConverter("thread-1", "run-1", WithConverterRegistry(registry))
+
+	fps := 24.0
+	adkEvent := &session.Event{
+		LLMResponse: model.LLMResponse{
+			Content: &genai.Content{
+				Parts: []*genai.Part{
+					{FileData: &genai.FileData{FileURI: "gs://bucket/video.mp4"}, VideoMetadata: &genai.VideoMetadata{FPS: &fps}},
+				},
+			},
+		},
+	}
+
+	evts := conv.ConvertEvent(adkEvent)
+
+	// Built-in file_data first, then the added kind
+	require.Len(t, evts, 2)
+	assert.Equal(t, "file_data", evts[0].(*events.CustomEvent).Name)
+	assert.Equal(t, "video_metadata", evts[1].(*events.CustomEvent).Name)
+}
+
+func TestConverterRegistry_RegisterAction(t *testing.T) {
+	registry := NewConverterRegistry()
+	registry.RegisterAction(ActionKindEscalate, func(c *ADKConverter, adkEvent *session.Event, _ *session.EventActions) []events.Event {
+		return []events.Event{events.NewRunErrorEvent("escalated by "+adkEvent.Author, events.WithRunID(c.GetRunID()))}
+	})
+	registry.AddAction("skip_summarization",
+		func(a *session.EventActions) bool { return a.SkipSummarization },
+		func(*ADKConverter, *session.Event, *session.EventActions) []events.Event {
+			return []events.Event{events.NewCustomEvent("skip_summarization")}
+		},
+	)
+	conv := NewADKConverter("thread-1", "run-1", WithConverterRegistry(registry))
+
+	adkEvent := &session.Event{
+		Author: "planner",
+		Actions: session.EventActions{
+			Escalate:          true,
+			SkipSummarization: true,
+		},
+	}
+
+	evts := conv.ConvertEvent(adkEvent)
+
+	require.Len(t, evts, 2)
+	runErr, ok := evts[0].(*events.RunErrorEvent)
+	require.True(t, ok)
+	assert.Equal(t, "escalated by planner", runErr.Message)
+	assert.Equal(t, "skip_summarization", evts[1].(*events.CustomEvent).Name)
+
+	assert.NotNil(t, BuiltinActionConverter(ActionKindStateDelta))
+	assert.Panics(t, func() { registry.RegisterAction("escalation", nil) })
+}
@@ -0,0 +1,161 @@
+package aguigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// RUN_ERROR codes a RemoteAgent reports when the upstream agent fails
+const (
+	// RemoteErrorUnavailable: the upstream could not be reached or answered 5xx
+	RemoteErrorUnavailable = "UPSTREAM_UNAVAILABLE"
+	// RemoteErrorRejected: the upstream answered 4xx
+	RemoteErrorRejected = "UPSTREAM_REJECTED"
+	// RemoteErrorTimeout: the upstream run took longer than the timeout
+	RemoteErrorTimeout = "UPSTREAM_TIMEOUT"
+	// RemoteErrorStream: the upstream stream broke off or could not be read
+	RemoteErrorStream = "UPSTREAM_STREAM_ERROR"
+)
+
+// errUpstreamTimeout is the cancellation cause of an upstream run that
+// exceeded the timeout
+var errUpstreamTimeout = errors.New("upstream agent timed out")
+
+// RemoteAgent is an event source that forwards each run to an upstream
+// AG-UI endpoint and streams its events back, so a gateway Handler can add
+// auth, logging and routing in front of agents that stay internal. Upstream
+// failures end the run with a RUN_ERROR carrying one of the RemoteError codes;
+// the messages don't reveal the upstream address.
+type RemoteAgent struct {
+	client  *Client
+	forward []string
+	timeout time.Duration
+	logger  Logger
+}
+
+// NewRemoteAgent creates a source for the agent at the upstream endpoint
+func NewRemoteAgent(endpoint string) *RemoteAgent {
+	return &RemoteAgent{client: NewClient(endpoint), logger: defaultLogger{}}
+}
+
+// WithClient sets the client used to call the upstream, for its HTTP
+// client, static headers, format and reconnects. Forwarded headers and
+// X-User-ID replace a static header of the same name.
+func (a *RemoteAgent) WithClient(c *Client) *RemoteAgent {
+	if c != nil {
+		a.client = c
+	}
+	return a
+}
+
+// WithForwardHeaders copies the named headers of the incoming request to the
+// upstream request, such as Authorization or traceparent. The user ID is
+// always sent as X-User-ID. A forwarded header replaces the client's static
+// header of the same name.
+func (a *RemoteAgent) WithForwardHeaders(names ...string) *RemoteAgent {
+	a.forward = append(a.forward, names...)
+	return a
+}
+
+// WithTimeout ends upstream runs that take longer than d
+func (a *RemoteAgent) WithTimeout(d time.Duration) *RemoteAgent {
+	a.timeout = d
+	return a
+}
+
+// WithLogger sets the logger upstream failures are reported to in full
+func (a *RemoteAgent) WithLogger(logger Logger) *RemoteAgent {
+	if logger != nil {
+		a.logger = logger
+	}
+	return a
+}
+
+// Run implements EventSource
+func (a *RemoteAgent) Run(ctx HandlerContext, input RunAgentInput) <-chan events.Event {
+	return SeqToChan(ctx, a.Events(ctx, input))
+}
+
+// Events implements IterEventSource
+func (a *RemoteAgent) Events(hctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+	return func(yield func(events.Event, error) bool) {
+		parent := hctx.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+		ctx, cancel := parent, context.CancelFunc(func() {})
+		if a.timeout > 0 {
+			ctx, cancel = context.WithTimeoutCause(parent, a.timeout, errUpstreamTimeout)
+		}
+		defer cancel()
+
+		input.ThreadID, input.RunID = hctx.ThreadID, hctx.RunID
+		for evt, err := range a.client.run(ctx, input, a.header(hctx)) {
+			if err == nil {
+				if !yield(evt, nil) {
+					return
+				}
+				continue
+			}
+
+			var decodeErr *eventDecodeError
+			if errors.As(err, &decodeErr) {
+				a.logger.Printf("[AG-UI] Skipping upstream event: %v", err)
+				continue
+			}
+			// The handler reports its own cancellation
+			if parent.Err() != nil {
+				return
+			}
+			a.logger.Printf("[AG-UI] Upstream agent failed: %v", err)
+			yield(a.runError(ctx, hctx, err), nil)
+			return
+		}
+	}
+}
+
+// header returns the headers to send upstream for the run
+func (a *RemoteAgent) header(hctx HandlerContext) http.Header {
+	header := make(http.Header)
+	if hctx.Request != nil {
+		for _, name := range a.forward {
+			if values := hctx.Request.Header.Values(name); len(values) > 0 {
+				header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+			}
+		}
+	}
+	if hctx.UserID != "" {
+		header.Set("X-User-ID", hctx.UserID)
+	}
+	return header
+}
+
+// runError maps an upstream failure to a RUN_ERROR
+func (a *RemoteAgent) runError(ctx context.Context, hctx HandlerContext, err error) events.Event {
+	code, msg := RemoteErrorStream, "upstream agent stream failed"
+
+	var statusErr *StatusError
+	var urlErr *url.Error
+	switch {
+	case errors.Is(context.Cause(ctx), errUpstreamTimeout):
+		code, msg = RemoteErrorTimeout, fmt.Sprintf("upstream agent timed out after %s", a.timeout)
+	case errors.As(err, &statusErr) && statusErr.StatusCode < 500:
+		// The body may hold upstream details; it is only logged
+		code = RemoteErrorRejected
+		msg = fmt.Sprintf("upstream agent rejected the run: %d %s", statusErr.StatusCode, http.StatusText(statusErr.StatusCode))
+	case statusErr != nil:
+		code = RemoteErrorUnavailable
+		msg = fmt.Sprintf("upstream agent failed: %d %s", statusErr.StatusCode, http.StatusText(statusErr.StatusCode))
+	case errors.As(err, &urlErr) && urlErr.Op == "Post":
+		code, msg = RemoteErrorUnavailable, "upstream agent is unavailable"
+	}
+
+	return events.NewRunErrorEvent(msg, events.WithErrorCode(code), events.WithRunID(hctx.RunID))
+}
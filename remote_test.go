@@ -0,0 +1,161 @@
+package aguigo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteAgent_Proxy(t *testing.T) {
+	var upstreamReq *http.Request
+	var upstreamInput RunAgentInput
+	upstream := httptest.NewServer(New(Config{
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			upstreamReq, upstreamInput = ctx.Request, input
+			return textSeq("m1", "Hello from upstream")
+		}),
+	}))
+	defer upstream.Close()
+
+	gateway := New(Config{
+		EventSource: NewRemoteAgent(upstream.URL).
+			WithClient(NewClient(upstream.URL).WithHeader("Authorization", "Bearer gateway").WithHeader("X-Gateway", "1")).
+			WithForwardHeaders("Authorization"),
+	})
+
+	body := `{"threadId":"thread-1","runId":"run-1","messages":[{"id":"u1","role":"user","content":"Hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Accept", ContentTypeNDJSON)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-User-ID", "user-1")
+	req.Header.Set("Cookie", "session=1")
+	rr := httptest.NewRecorder()
+	gateway.ServeHTTP(rr, req)
+
+	types := ndjsonTypes(t, rr.Body.String())
+	assert.Equal(t, []string{"RUN_STARTED", "TEXT_MESSAGE_START", "TEXT_MESSAGE_CONTENT", "TEXT_MESSAGE_END", "RUN_FINISHED"}, types)
+	assert.Contains(t, rr.Body.String(), "Hello from upstream")
+
+	require.NotNil(t, upstreamReq)
+	// Forwarded headers replace the client's static ones
+	assert.Equal(t, []string{"Bearer secret"}, upstreamReq.Header.Values("Authorization"))
+	assert.Equal(t, "1", upstreamReq.Header.Get("X-Gateway"))
+	assert.Equal(t, "user-1", upstreamReq.Header.Get("X-User-ID"))
+	assert.Empty(t, upstreamReq.Header.Get("Cookie"))
+	assert.Equal(t, "thread-1", upstreamInput.ThreadID)
+	assert.Equal(t, "run-1", upstreamInput.RunID)
+	require.Len(t, upstreamInput.Messages, 1)
+	assert.Equal(t, "Hi", upstreamInput.Messages[0].Content[0].Text)
+}
+
+func TestRemoteAgent_Errors(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+		timeout time.Duration
+		code    string
+		message string
+		logged  string
+	}{
+		{
+			name:    "unreachable",
+			url:     closedURL,
+			code:    RemoteErrorUnavailable,
+			message: "upstream agent is unavailable",
+		},
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "database is down", http.StatusServiceUnavailable)
+			},
+			code:    RemoteErrorUnavailable,
+			message: "upstream agent failed: 503 Service Unavailable",
+		},
+		{
+			name: "rejected",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Run already exists", http.StatusConflict)
+			},
+			code:    RemoteErrorRejected,
+			message: "upstream agent rejected the run: 409 Conflict",
+			logged:  "Run already exists",
+		},
+		{
+			name: "dropped stream",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", ContentTypeSSE)
+				io.WriteString(w, "data: {\"type\":\"RUN_STARTED\",\"threadId\":\"t\",\"runId\":\"r\"}\n\n")
+			},
+			code:    RemoteErrorStream,
+			message: "upstream agent stream failed",
+		},
+		{
+			name: "timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", ContentTypeSSE)
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			},
+			timeout: 50 * time.Millisecond,
+			code:    RemoteErrorTimeout,
+			message: "upstream agent timed out after 50ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := tt.url
+			if tt.handler != nil {
+				srv := httptest.NewServer(tt.handler)
+				defer srv.Close()
+				url = srv.URL
+			}
+
+			var logged []string
+			agent := NewRemoteAgent(url).WithTimeout(tt.timeout).WithLogger(loggerFunc(func(format string, v ...any) {
+				logged = append(logged, fmt.Sprintf(format, v...))
+			}))
+			evts := collectEvents(t, agent.Events(HandlerContext{ThreadID: "t", RunID: "r", Context: context.Background()}, RunAgentInput{}))
+			require.NotEmpty(t, evts)
+
+			runErr, ok := evts[len(evts)-1].(*events.RunErrorEvent)
+			require.True(t, ok, "last event is %s", evts[len(evts)-1].Type())
+			assert.Equal(t, tt.code, *runErr.Code)
+			assert.Equal(t, tt.message, runErr.Message)
+			assert.Equal(t, "r", runErr.RunIDValue)
+			assert.NotContains(t, runErr.Message, strings.TrimPrefix(url, "http://"))
+			if tt.logged != "" {
+				assert.Contains(t, strings.Join(logged, "\n"), tt.logged)
+			}
+		})
+	}
+
+	t.Run("cancellation is left to the handler", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ContentTypeSSE)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+		defer srv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		evts := collectEvents(t, NewRemoteAgent(srv.URL).Events(HandlerContext{ThreadID: "t", RunID: "r", Context: ctx}, RunAgentInput{}))
+		assert.Empty(t, evts)
+	})
+}
@@ -0,0 +1,117 @@
+package aguigo
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// ContentTypeResult is the compact response: the run is replayed on the
+// server and answered with a single RunResult once it ends
+const ContentTypeResult = "application/vnd.ag-ui.result+json"
+
+// UsageEventName is the name of the CUSTOM event that reports token usage;
+// its value is a Usage. A run may report usage several times, once per
+// model call, and the counts add up.
+const UsageEventName = "usage"
+
+// Usage counts the tokens a run used
+type Usage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	ThoughtsTokens   int `json:"thoughtsTokens,omitempty"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// RunResult is the final outcome of a run
+type RunResult struct {
+	ThreadID string `json:"threadId"`
+	RunID    string `json:"runId"`
+	// Status is RunStateFinished or RunStateFailed, or RunStateRunning if
+	// the run stopped without a terminal event
+	Status string `json:"status"`
+	// Error and Code describe why a failed run failed
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+	// Messages are the messages the run produced, with their tool calls and
+	// tool results
+	Messages []events.Message `json:"messages"`
+	// State is the final state snapshot, if the run had state
+	State any `json:"state,omitempty"`
+	// Result is the result carried by RUN_FINISHED, if any
+	Result any    `json:"result,omitempty"`
+	Usage  *Usage `json:"usage,omitempty"`
+}
+
+// runResultCollector replays a run's events into a RunResult
+type runResultCollector struct {
+	reducer *Reducer
+	usage   *Usage
+}
+
+// newRunResultCollector creates a collector for a run that starts from state
+func newRunResultCollector(state any) *runResultCollector {
+	c := &runResultCollector{reducer: NewReducer()}
+	if state != nil {
+		c.reducer.SeedState(state)
+	}
+	return c
+}
+
+// apply records one event. Events the reducer rejects are left out of the
+// result.
+func (c *runResultCollector) apply(evt events.Event) {
+	c.reducer.Apply(evt)
+
+	custom, ok := evt.(*events.CustomEvent)
+	if !ok || custom.Name != UsageEventName {
+		return
+	}
+	data, err := json.Marshal(custom.Value)
+	if err != nil {
+		return
+	}
+	var u Usage
+	if err := json.Unmarshal(data, &u); err != nil {
+		return
+	}
+	if c.usage == nil {
+		c.usage = &Usage{}
+	}
+	c.usage.PromptTokens += u.PromptTokens
+	c.usage.CompletionTokens += u.CompletionTokens
+	c.usage.ThoughtsTokens += u.ThoughtsTokens
+	c.usage.TotalTokens += u.TotalTokens
+}
+
+// result describes the run as it stands
+func (c *runResultCollector) result(hctx HandlerContext) RunResult {
+	conv := c.reducer.Conversation()
+	res := RunResult{
+		ThreadID: conv.ThreadID,
+		RunID:    conv.RunID,
+		Status:   conv.Status,
+		Error:    conv.Error,
+		Code:     conv.Code,
+		Messages: c.reducer.Messages(),
+		State:    conv.State,
+		Result:   conv.Result,
+		Usage:    c.usage,
+	}
+	if res.ThreadID == "" {
+		res.ThreadID, res.RunID = hctx.ThreadID, hctx.RunID
+	}
+	if res.Status == "" {
+		res.Status = RunStateRunning
+	}
+	return res
+}
+
+// writeRunResult answers with the outcome of a run
+func writeRunResult(w http.ResponseWriter, res RunResult) {
+	w.Header().Set("Content-Type", ContentTypeResult)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,184 @@
+package aguigo
+
+import (
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+func TestRunResultCollector(t *testing.T) {
+	t.Run("finished run", func(t *testing.T) {
+		c := newRunResultCollector(nil)
+		for _, evt := range []events.Event{
+			events.NewRunStartedEvent("thread-1", "run-1"),
+			events.NewToolCallStartEvent("call-1", "search", events.WithParentMessageID("m1")),
+			events.NewToolCallArgsEvent("call-1", `{"q":"go"}`),
+			events.NewToolCallEndEvent("call-1"),
+			events.NewToolCallResultEvent("m2", "call-1", "found"),
+			events.NewTextMessageStartEvent("m3", events.WithRole("assistant")),
+			events.NewTextMessageContentEvent("m3", "Done"),
+			events.NewTextMessageEndEvent("m3"),
+			events.NewStateSnapshotEvent(map[string]any{"count": 1}),
+			events.NewStateDeltaEvent([]events.JSONPatchOperation{{Op: "replace", Path: "/count", Value: 2}}),
+			events.NewCustomEvent(UsageEventName, events.WithValue(Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15})),
+			events.NewCustomEvent(UsageEventName, events.WithValue(map[string]any{"promptTokens": 20, "completionTokens": 3, "totalTokens": 23})),
+			events.NewRunFinishedEvent("thread-1", "run-1"),
+		} {
+			c.apply(evt)
+		}
+
+		res := c.result(HandlerContext{})
+		assert.Equal(t, "thread-1", res.ThreadID)
+		assert.Equal(t, "run-1", res.RunID)
+		assert.Equal(t, RunStateFinished, res.Status)
+		assert.Empty(t, res.Error)
+		assert.Equal(t, map[string]any{"count": float64(2)}, res.State)
+		assert.Equal(t, &Usage{PromptTokens: 30, CompletionTokens: 8, TotalTokens: 38}, res.Usage)
+
+		require.Len(t, res.Messages, 3)
+		assert.Equal(t, "assistant", res.Messages[0].Role)
+		require.Len(t, res.Messages[0].ToolCalls, 1)
+		assert.Equal(t, `{"q":"go"}`, res.Messages[0].ToolCalls[0].Function.Arguments)
+		assert.Equal(t, "tool", res.Messages[1].Role)
+		assert.Equal(t, "call-1", *res.Messages[1].ToolCallID)
+		assert.Equal(t, "found", *res.Messages[1].Content)
+		assert.Equal(t, "Done", *res.Messages[2].Content)
+	})
+
+	t.Run("failed run", func(t *testing.T) {
+		c := newRunResultCollector(nil)
+		c.apply(events.NewRunErrorEvent("boom", events.WithErrorCode("AGENT_ERROR")))
+
+		res := c.result(HandlerContext{ThreadID: "thread-1", RunID: "run-1"})
+		assert.Equal(t, "thread-1", res.ThreadID)
+		assert.Equal(t, RunStateFailed, res.Status)
+		assert.Equal(t, "boom", res.Error)
+		assert.Equal(t, "AGENT_ERROR", res.Code)
+		assert.Nil(t, res.Usage)
+		assert.NotNil(t, res.Messages)
+	})
+}
+
+func TestHandler_Result(t *testing.T) {
+	handler := New(Config{
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			if input.RunID == "fails" {
+				return func(yield func(events.Event, error) bool) {
+					yield(nil, errors.New("boom"))
+				}
+			}
+			return textSeq("m1", "Hello", " world")
+		}),
+	})
+
+	serve := func(runID string) RunResult {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"threadId":"thread-1","runId":"`+runID+`"}`))
+		req.Header.Set("Accept", ContentTypeResult)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, ContentTypeResult, rr.Header().Get("Content-Type"))
+		var res RunResult
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		return res
+	}
+
+	res := serve("run-1")
+	assert.Equal(t, RunStateFinished, res.Status)
+	assert.Equal(t, "run-1", res.RunID)
+	require.Len(t, res.Messages, 1)
+	assert.Equal(t, "Hello world", *res.Messages[0].Content)
+
+	res = serve("fails")
+	assert.Equal(t, RunStateFailed, res.Status)
+	assert.Equal(t, "boom", res.Error)
+}
+
+func TestADKConverter_Usage(t *testing.T) {
+	usage := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 7, CandidatesTokenCount: 3, TotalTokenCount: 10}
+
+	// Usage events are off by default
+	assert.Empty(t, NewADKConverter("thread-1", "run-1").ConvertEvent(&session.Event{LLMResponse: model.LLMResponse{UsageMetadata: usage}}))
+
+	conv := NewADKConverter("thread-1", "run-1", WithUsageEvents(true))
+
+	partial := &session.Event{LLMResponse: model.LLMResponse{UsageMetadata: usage, Partial: true}}
+	assert.Empty(t, conv.ConvertEvent(partial))
+
+	final := &session.Event{LLMResponse: model.LLMResponse{UsageMetadata: usage}}
+	evts := conv.ConvertEvent(final)
+	require.Len(t, evts, 1)
+	custom := evts[0].(*events.CustomEvent)
+	assert.Equal(t, UsageEventName, custom.Name)
+	assert.Equal(t, Usage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}, custom.Value)
+}
+
+func TestADKHandler_Result(t *testing.T) {
+	handler := newTestADKHandler(t, []model.LLMResponse{{
+		Content:       genai.NewContentFromText("Hello", genai.RoleModel),
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 4, CandidatesTokenCount: 1, TotalTokenCount: 5},
+	}})
+
+	body := `{"threadId":"thread-1","runId":"run-1","messages":[{"id":"m1","role":"user","content":"Hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Accept", ContentTypeResult)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var res RunResult
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	assert.Equal(t, RunStateFinished, res.Status)
+	assert.Equal(t, "thread-1", res.ThreadID)
+	require.Len(t, res.Messages, 1)
+	assert.Equal(t, "Hello", *res.Messages[0].Content)
+	assert.Equal(t, &Usage{PromptTokens: 4, CompletionTokens: 1, TotalTokens: 5}, res.Usage)
+
+	// The event stream leaves usage out unless asked for
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.NotContains(t, rr.Body.String(), `"name":"usage"`)
+}
+
+func TestADKHandler_ResultState(t *testing.T) {
+	ag, err := agent.New(agent.Config{
+		Name: "stateful",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				evt := session.NewEvent(ctx.InvocationID())
+				evt.Author = "stateful"
+				evt.Content = genai.NewContentFromText("Counted", genai.RoleModel)
+				evt.Actions.StateDelta = map[string]any{"count": 2, "done": true}
+				yield(evt, nil)
+			}
+		},
+	})
+	require.NoError(t, err)
+	handler, err := NewADKHandler(ag, session.InMemoryService(), "test-app")
+	require.NoError(t, err)
+
+	body := `{"threadId":"thread-1","runId":"run-1","state":{"count":1,"city":"Paris"},"messages":[{"id":"m1","role":"user","content":"Count"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Accept", ContentTypeResult)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var res RunResult
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	require.Equal(t, RunStateFinished, res.Status, res.Error)
+	assert.Equal(t, map[string]any{"count": float64(2), "done": true, "city": "Paris"}, res.State)
+}
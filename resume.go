@@ -0,0 +1,269 @@
+package aguigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+const (
+	// defaultReplayBufferSize is the number of events kept per run for replay
+	defaultReplayBufferSize = 1024
+	// defaultStreamRetention is how long a finished run stays available for replay
+	defaultStreamRetention = 2 * time.Minute
+)
+
+// ErrReplayUnavailable is returned when the events after a Last-Event-ID
+// are no longer buffered
+var ErrReplayUnavailable = errors.New("events are no longer available for replay")
+
+// StreamStore buffers the events of recent runs so SSE clients can
+// reconnect. Each event gets a per-run sequence number, written as the SSE
+// id. A request carrying Last-Event-ID for a known run gets the missed events
+// replayed and then follows the live run, which keeps going while no client
+// is connected. Each run is broadcast through a Hub, so any number of
+// clients can follow it.
+type StreamStore struct {
+	mu        sync.Mutex
+	streams   map[runKey]*runStream
+	hub       HubConfig
+	retention time.Duration
+	userID    UserIDFunc
+	mux       *http.ServeMux
+}
+
+// NewStreamStore creates a store that keeps the last bufferSize events of
+// each run. A bufferSize of zero or less uses the default of 1024.
+func NewStreamStore(bufferSize int) *StreamStore {
+	s := &StreamStore{
+		streams:   make(map[runKey]*runStream),
+		hub:       HubConfig{ReplaySize: bufferSize},
+		retention: defaultStreamRetention,
+		userID:    UserIDHeader("X-User-ID"),
+		mux:       http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /{threadID}/{runID}", s.handleStatus)
+	s.mux.HandleFunc("GET /{threadID}/{runID}/events", s.handleEvents)
+
+	return s
+}
+
+// WithRetention sets how long a finished run stays available for replay
+func (s *StreamStore) WithRetention(d time.Duration) *StreamStore {
+	s.retention = d
+	return s
+}
+
+// WithBackpressure sets how runs treat SSE clients that fall more than
+// queueSize events behind. A queueSize of zero or less uses the default of
+// 256. BackpressureBuffer ignores queueSize and queues up to the default
+// HubConfig.BufferLimit.
+func (s *StreamStore) WithBackpressure(policy BackpressurePolicy, queueSize int) *StreamStore {
+	s.hub.Backpressure = policy
+	s.hub.QueueSize = queueSize
+	return s
+}
+
+// WithKeepAlive sets the SSE heartbeat and reconnect delay of the stream
+// endpoints
+func (s *StreamStore) WithKeepAlive(keepAlive SSEKeepAlive) *StreamStore {
+	s.hub.KeepAlive = keepAlive
+	return s
+}
+
+// WithUserID resolves the caller of the stream endpoints with fn, like
+// RunRegistry.WithUserID
+func (s *StreamStore) WithUserID(fn UserIDFunc) *StreamStore {
+	s.userID = fn
+	return s
+}
+
+// Status returns the state of a buffered run
+func (s *StreamStore) Status(threadID, runID string) (RunStatus, bool) {
+	stream, ok := s.get(threadID, runID)
+	if !ok {
+		return RunStatus{}, false
+	}
+	return stream.status(), true
+}
+
+// create adds a stream for a new run, failing if the run already exists.
+// The run's snapshot starts from the input's messages and state.
+func (s *StreamStore) create(info RunInfo, input RunAgentInput) (*runStream, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := runKey{info.ThreadID, info.RunID}
+	if _, ok := s.streams[key]; ok {
+		return nil, false
+	}
+	cfg := s.hub
+	cfg.Messages, cfg.State = input.Messages, input.State
+	stream := newRunStream(info, cfg)
+	s.streams[key] = stream
+	return stream, true
+}
+
+// get returns the stream of a run
+func (s *StreamStore) get(threadID, runID string) (*runStream, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, ok := s.streams[runKey{threadID, runID}]
+	return stream, ok
+}
+
+// finish closes a stream and forgets it once the retention period is over
+func (s *StreamStore) finish(threadID, runID string, stream *runStream) {
+	stream.close()
+
+	key := runKey{threadID, runID}
+	time.AfterFunc(s.retention, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.streams[key] == stream {
+			delete(s.streams, key)
+		}
+	})
+}
+
+// runStream is a run buffered in a StreamStore: a Hub plus the run's status
+type runStream struct {
+	*Hub
+
+	mu       sync.Mutex
+	info     RunInfo
+	done     bool
+	state    string
+	errMsg   string
+	finished time.Time
+}
+
+func newRunStream(info RunInfo, cfg HubConfig) *runStream {
+	return &runStream{
+		Hub:   NewHub(cfg),
+		info:  info,
+		state: RunStateRunning,
+	}
+}
+
+// publish tracks the run's state and broadcasts events
+func (s *runStream) publish(evts ...events.Event) {
+	s.mu.Lock()
+	for _, evt := range evts {
+		switch e := evt.(type) {
+		case *events.RunFinishedEvent:
+			s.state = RunStateFinished
+		case *events.RunErrorEvent:
+			s.state, s.errMsg = RunStateFailed, e.Message
+		}
+	}
+	s.mu.Unlock()
+
+	s.Publish(evts...)
+}
+
+// close marks the run as finished
+func (s *runStream) close() {
+	s.mu.Lock()
+	s.done = true
+	s.finished = time.Now()
+	if s.state == RunStateRunning {
+		// The stream ended without a terminal event
+		s.state = RunStateFinished
+	}
+	s.mu.Unlock()
+
+	s.Close()
+}
+
+// status reports the run's progress
+func (s *runStream) status() RunStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := RunStatus{
+		RunInfo:     s.info,
+		State:       s.state,
+		Error:       s.errMsg,
+		LastEventID: s.LastEventID(),
+	}
+	if s.done {
+		finished := s.finished
+		st.FinishedAt = &finished
+	}
+	return st
+}
+
+// serveResumableSSE serves an SSE request through a StreamStore. A request
+// with Last-Event-ID follows an existing run; any other request starts a
+// run detached from the connection by calling stream in the background.
+func serveResumableSSE(w http.ResponseWriter, r *http.Request, store *StreamStore, runs *runScope, hctx HandlerContext, input RunAgentInput, keepAlive SSEKeepAlive, stream func(HandlerContext, func([]events.Event) bool)) {
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		rs, ok := store.get(hctx.ThreadID, hctx.RunID)
+		if !ok || !ownsRun(store.userID, r, rs.info) {
+			http.Error(w, ErrRunNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		sub, err := rs.subscribeRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), subscribeErrorStatus(err))
+			return
+		}
+		serveSubscription(w, r, sub, keepAlive)
+		return
+	}
+
+	rs, ok := store.create(runInfo(hctx), input)
+	if !ok {
+		http.Error(w, "Run already exists", http.StatusConflict)
+		return
+	}
+	// Subscribe before the run starts so no event is missed
+	sub, _ := rs.SubscribeAfter(0)
+	runDetached(r, store, runs, hctx, rs, stream)
+	serveSubscription(w, r, sub, keepAlive)
+}
+
+// runInfo describes the run of a request
+func runInfo(hctx HandlerContext) RunInfo {
+	return RunInfo{
+		ThreadID:  hctx.ThreadID,
+		RunID:     hctx.RunID,
+		UserID:    hctx.UserID,
+		StartedAt: time.Now(),
+	}
+}
+
+// runDetached calls stream in the background, publishing to rs. The run
+// outlives the request: only shutdown, timeout or the run registry can
+// cancel it.
+func runDetached(r *http.Request, store *StreamStore, runs *runScope, hctx HandlerContext, rs *runStream, stream func(HandlerContext, func([]events.Event) bool)) {
+	runCtx, cancel := runs.runContext(context.WithoutCancel(r.Context()), hctx)
+	hctx.Context = runCtx
+	go func() {
+		defer cancel()
+		defer store.finish(hctx.ThreadID, hctx.RunID, rs)
+		stream(hctx, func(evts []events.Event) bool {
+			rs.publish(evts...)
+			return true
+		})
+	}()
+}
+
+// writeSSEEvent writes one event as an SSE frame with the given id
+func writeSSEEvent(w io.Writer, id string, evt events.Event) error {
+	data, err := evt.ToJSON()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\ndata: %s\n\n", id, data)
+	return err
+}
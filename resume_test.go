@@ -0,0 +1,99 @@
+package aguigo
+
+import (
+	"bytes"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ResumableSSE(t *testing.T) {
+	gate := make(chan struct{})
+	handler := New(Config{
+		Streams: NewStreamStore(0),
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return func(yield func(events.Event, error) bool) {
+				if !yield(events.NewCustomEvent("before"), nil) {
+					return
+				}
+				<-gate
+				yield(events.NewCustomEvent("after"), nil)
+			}
+		}),
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	post := func(lastEventID string) *http.Response {
+		body, _ := json.Marshal(RunAgentInput{ThreadID: "thread-1", RunID: "run-1"})
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+		req.Header.Set("Accept", "text/event-stream")
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// The first client disconnects after the first content event
+	resp := post("")
+	frames := readSSEFrames(t, resp.Body, func(f sseFrame) bool { return f.Type == "CUSTOM" })
+	resp.Body.Close()
+	assert.Equal(t, []sseFrame{{ID: "1", Type: "RUN_STARTED"}, {ID: "2", Type: "CUSTOM"}}, frames)
+
+	// The run keeps going without a client
+	close(gate)
+
+	t.Run("duplicate run is rejected", func(t *testing.T) {
+		resp := post("")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("reconnect replays the missed events", func(t *testing.T) {
+		resp := post("2")
+		defer resp.Body.Close()
+
+		frames := readSSEFrames(t, resp.Body, nil)
+		assert.Equal(t, []sseFrame{{ID: "3", Type: "CUSTOM"}, {ID: "4", Type: "RUN_FINISHED"}}, frames)
+	})
+
+	t.Run("unknown run", func(t *testing.T) {
+		body := `{"threadId":"thread-1","runId":"other"}`
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+		req.Header.Set("Last-Event-ID", "1")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestADKHandler_ResumableSSE(t *testing.T) {
+	handler := newTestADKHandler(t, nil, WithStreamStore(NewStreamStore(0).WithRetention(time.Minute)))
+
+	body := `{"threadId":"thread-1","runId":"run-1","messages":[{"id":"m1","role":"user","content":"Hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	frames := readSSEFrames(t, rr.Body, nil)
+	assert.Equal(t, []string{"RUN_STARTED", "RUN_FINISHED"}, frameTypes(frames))
+	assert.Equal(t, "2", frames[1].ID)
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Last-Event-ID", "1")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, []string{"RUN_FINISHED"}, frameTypes(readSSEFrames(t, rr.Body, nil)))
+}
@@ -0,0 +1,214 @@
+package aguigo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+)
+
+// maxRoutedBodySize is the largest request body the router reads to find a
+// forwarded prop
+const maxRoutedBodySize = 10 << 20
+
+// AgentRouter serves several named ADK agents from one handler. Each agent
+// gets its own ADKHandler, with the agent's name as its app name, and each
+// request is sent to the agent it names by path segment, header or forwarded
+// prop. A request for an unknown agent is answered 404 with the available agents.
+type AgentRouter struct {
+	mu             sync.RWMutex
+	handlers       map[string]*ADKHandler
+	sessionService session.Service
+	opts           []Option
+
+	pathPrefix   string
+	header       string
+	prop         string
+	defaultAgent string
+}
+
+// NewAgentRouter creates a router whose agents use sessionService unless they
+// are added with their own, and the given handler options
+func NewAgentRouter(sessionService session.Service, opts ...Option) *AgentRouter {
+	return &AgentRouter{
+		handlers:       make(map[string]*ADKHandler),
+		sessionService: sessionService,
+		opts:           opts,
+	}
+}
+
+// WithAgentPath selects the agent by the path segment after prefix, so with
+// the prefix "/agents/" a request to /agents/research runs the "research" agent
+func (rt *AgentRouter) WithAgentPath(prefix string) *AgentRouter {
+	rt.pathPrefix = prefix
+	return rt
+}
+
+// WithAgentHeader selects the agent by the named request header
+func (rt *AgentRouter) WithAgentHeader(name string) *AgentRouter {
+	rt.header = name
+	return rt
+}
+
+// WithAgentProp selects the agent by the named key of the input's forwardedProps
+func (rt *AgentRouter) WithAgentProp(key string) *AgentRouter {
+	rt.prop = key
+	return rt
+}
+
+// WithDefaultAgent runs the named agent when a request doesn't name one
+func (rt *AgentRouter) WithDefaultAgent(name string) *AgentRouter {
+	rt.defaultAgent = name
+	return rt
+}
+
+// Add registers an agent under name. A nil sessionService uses the router's.
+// Adding a name again replaces its agent and shuts the old agent's handler
+// down, cancelling its runs in flight; new requests go to the new agent.
+func (rt *AgentRouter) Add(name string, ag agent.Agent, sessionService session.Service) error {
+	if name == "" {
+		return errors.New("agent name is required")
+	}
+	if sessionService == nil {
+		sessionService = rt.sessionService
+	}
+
+	h, err := NewADKHandler(ag, sessionService, name, rt.opts...)
+	if err != nil {
+		return fmt.Errorf("agent %q: %w", name, err)
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if old, ok := rt.handlers[name]; ok {
+		old.Shutdown()
+	}
+	rt.handlers[name] = h
+	return nil
+}
+
+// Handler returns the handler of the named agent, for its WebSocket or
+// Streams
+func (rt *AgentRouter) Handler(name string) (*ADKHandler, bool) {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	h, ok := rt.handlers[name]
+	return h, ok
+}
+
+// Agents returns the names of the registered agents, sorted
+func (rt *AgentRouter) Agents() []string {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	names := make([]string, 0, len(rt.handlers))
+	for name := range rt.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Shutdown cancels the in-flight runs of every agent
+func (rt *AgentRouter) Shutdown() {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	for _, h := range rt.handlers {
+		h.Shutdown()
+	}
+}
+
+// ServeHTTP sends the request to the agent it names
+func (rt *AgentRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", rt.allowedHeaders())
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	name, err := rt.agentName(w, r)
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, fmt.Sprintf("Failed to read request: %v", err), status)
+		return
+	}
+
+	h, ok := rt.Handler(name)
+	if !ok {
+		msg := fmt.Sprintf("unknown agent %q", name)
+		if name == "" {
+			msg = "no agent selected"
+		}
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		writeJSON(w, http.StatusNotFound, map[string]any{
+			"error":  msg,
+			"agents": rt.Agents(),
+		})
+		return
+	}
+
+	h.ServeHTTP(w, r)
+}
+
+// agentName returns the agent a request names, checking the path, the
+// header and the forwarded props in that order
+func (rt *AgentRouter) agentName(w http.ResponseWriter, r *http.Request) (string, error) {
+	if rt.pathPrefix != "" {
+		if rest, ok := strings.CutPrefix(r.URL.Path, rt.pathPrefix); ok {
+			if name, _, _ := strings.Cut(rest, "/"); name != "" {
+				return name, nil
+			}
+		}
+	}
+
+	if rt.header != "" {
+		if name := r.Header.Get(rt.header); name != "" {
+			return name, nil
+		}
+	}
+
+	if rt.prop != "" && r.Method == http.MethodPost && r.Body != nil {
+		// The agent's handler reads the body again
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRoutedBodySize))
+		r.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var input struct {
+			ForwardedProps any `json:"forwardedProps"`
+		}
+		// An invalid body is left for the agent's handler to reject
+		json.Unmarshal(body, &input)
+		props, _ := input.ForwardedProps.(map[string]any)
+		if name, ok := props[rt.prop].(string); ok && name != "" {
+			return name, nil
+		}
+	}
+
+	return rt.defaultAgent, nil
+}
+
+// allowedHeaders lists the CORS request headers, including the agent header
+func (rt *AgentRouter) allowedHeaders() string {
+	headers := "Content-Type, Accept, Authorization, X-User-ID, Last-Event-ID"
+	if rt.header != "" {
+		headers += ", " + rt.header
+	}
+	return headers
+}
@@ -0,0 +1,111 @@
+package aguigo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/session"
+)
+
+func TestAgentRouter(t *testing.T) {
+	shared, own := session.InMemoryService(), session.InMemoryService()
+	router := NewAgentRouter(shared).
+		WithAgentPath("/agents/").
+		WithAgentHeader("X-Agent").
+		WithAgentProp("agent")
+	require.NoError(t, router.Add("research", newEchoAgent(t, "research"), nil))
+	require.NoError(t, router.Add("writer", newEchoAgent(t, "writer"), own))
+	assert.Equal(t, []string{"research", "writer"}, router.Agents())
+
+	post := func(path, body string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Accept", ContentTypeResult)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	answer := func(t *testing.T, rr *httptest.ResponseRecorder) string {
+		t.Helper()
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var res RunResult
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		require.Len(t, res.Messages, 1)
+		return *res.Messages[0].Content
+	}
+
+	body := `{"threadId":"thread-1","messages":[{"id":"u1","role":"user","content":"Hi"}]}`
+
+	t.Run("path", func(t *testing.T) {
+		assert.Equal(t, "research: Hi", answer(t, post("/agents/research", body, nil)))
+		assert.Equal(t, "writer: Hi", answer(t, post("/agents/writer/", body, nil)))
+	})
+
+	t.Run("header", func(t *testing.T) {
+		assert.Equal(t, "writer: Hi", answer(t, post("/", body, http.Header{"X-Agent": {"writer"}})))
+		// The path wins over the header
+		assert.Equal(t, "research: Hi", answer(t, post("/agents/research", body, http.Header{"X-Agent": {"writer"}})))
+	})
+
+	t.Run("forwarded prop", func(t *testing.T) {
+		propBody := `{"threadId":"thread-2","messages":[{"id":"u1","role":"user","content":"Hello"}],"forwardedProps":{"agent":"writer"}}`
+		assert.Equal(t, "writer: Hello", answer(t, post("/", propBody, nil)))
+	})
+
+	t.Run("forwarded prop body too large", func(t *testing.T) {
+		rr := post("/", `{"forwardedProps":{"agent":"writer"},"pad":"`+strings.Repeat("x", maxRoutedBodySize)+`"}`, nil)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+
+	t.Run("own session service", func(t *testing.T) {
+		_, err := own.Get(context.Background(), &session.GetRequest{AppName: "writer", UserID: "default-user", SessionID: "thread-1"})
+		assert.NoError(t, err)
+		_, err = shared.Get(context.Background(), &session.GetRequest{AppName: "writer", UserID: "default-user", SessionID: "thread-1"})
+		assert.Error(t, err)
+		_, err = shared.Get(context.Background(), &session.GetRequest{AppName: "research", UserID: "default-user", SessionID: "thread-1"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown agent", func(t *testing.T) {
+		for path, msg := range map[string]string{
+			"/agents/support": `unknown agent "support"`,
+			"/":               "no agent selected",
+		} {
+			rr := post(path, body, nil)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.JSONEq(t, `{"error":`+mustJSON(t, msg)+`,"agents":["research","writer"]}`, rr.Body.String())
+		}
+	})
+
+	t.Run("default agent", func(t *testing.T) {
+		router.WithDefaultAgent("research")
+		defer router.WithDefaultAgent("")
+		assert.Equal(t, "research: Hi", answer(t, post("/", body, nil)))
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodOptions, "/agents/unknown", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), "X-Agent")
+	})
+
+	t.Run("add requires a name", func(t *testing.T) {
+		assert.Error(t, router.Add("", newEchoAgent(t, "nameless"), nil))
+	})
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
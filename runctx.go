@@ -0,0 +1,77 @@
+package aguigo
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrRunTimeout is the cancellation cause of a run that exceeded its timeout
+var ErrRunTimeout = errors.New("run timed out")
+
+// ErrHandlerShutdown is the cancellation cause of runs stopped by Shutdown
+var ErrHandlerShutdown = errors.New("handler is shutting down")
+
+// runScope derives per-run contexts and cancels all of them on shutdown
+type runScope struct {
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	timeout  time.Duration
+	registry *RunRegistry
+}
+
+func newRunScope(timeout time.Duration, registry *RunRegistry) *runScope {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	return &runScope{ctx: ctx, cancel: cancel, timeout: timeout, registry: registry}
+}
+
+// runContext returns a context for the run described by hctx. It is
+// cancelled with parent (normally the request, so on client disconnect),
+// when the handler shuts down, the run timeout expires or the run is
+// cancelled through the registry. The returned cancel must be called once
+// the run is over.
+func (s *runScope) runContext(parent context.Context, hctx HandlerContext) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	stop := context.AfterFunc(s.ctx, func() { cancel(context.Cause(s.ctx)) })
+
+	unregister := func() {}
+	if s.registry != nil {
+		unregister = s.registry.register(RunInfo{
+			ThreadID:  hctx.ThreadID,
+			RunID:     hctx.RunID,
+			UserID:    hctx.UserID,
+			StartedAt: time.Now(),
+		}, cancel)
+	}
+
+	cancelTimeout := context.CancelFunc(func() {})
+	if s.timeout > 0 {
+		ctx, cancelTimeout = context.WithTimeoutCause(ctx, s.timeout, ErrRunTimeout)
+	}
+
+	return ctx, func() {
+		unregister()
+		stop()
+		cancelTimeout()
+		cancel(context.Canceled)
+	}
+}
+
+// shutdown cancels every run, current and future
+func (s *runScope) shutdown() {
+	s.cancel(ErrHandlerShutdown)
+}
+
+// clientGone reports whether the client of a streaming response has
+// disconnected, so there is no point in writing more events
+func clientGone(hctx HandlerContext) bool {
+	return hctx.Request != nil && hctx.Request.Context().Err() != nil
+}
+
+// runCancelled returns why ctx was cancelled, or nil if it is still live
+func runCancelled(ctx context.Context) error {
+	if ctx == nil || ctx.Err() == nil {
+		return nil
+	}
+	return context.Cause(ctx)
+}
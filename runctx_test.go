@@ -0,0 +1,110 @@
+package aguigo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingSource emits one event and then waits for the run to be cancelled
+func blockingSource(stopped chan<- error) StreamFunc {
+	return func(ctx HandlerContext, input RunAgentInput, emit func(events.Event)) error {
+		emit(events.NewCustomEvent("working"))
+		<-ctx.Done()
+		stopped <- context.Cause(ctx.Context)
+		return nil
+	}
+}
+
+func TestHandler_RunTimeout(t *testing.T) {
+	stopped := make(chan error, 1)
+	handler := New(Config{
+		EventSource: blockingSource(stopped),
+		RunTimeout:  20 * time.Millisecond,
+	})
+
+	evts := runJSON(t, handler, RunAgentInput{ThreadID: "thread-1", RunID: "run-1"})
+
+	assert.Equal(t, []string{"RUN_STARTED", "CUSTOM", "RUN_ERROR"}, jsonEventTypes(evts))
+	assert.Equal(t, ErrRunTimeout.Error(), evts[2]["message"])
+	assert.ErrorIs(t, <-stopped, ErrRunTimeout)
+}
+
+func TestHandler_Shutdown(t *testing.T) {
+	stopped := make(chan error, 1)
+	handler := New(Config{EventSource: blockingSource(stopped)})
+
+	body, _ := json.Marshal(RunAgentInput{ThreadID: "thread-1", RunID: "run-1"})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Accept", "application/json")
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		done <- rr
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	handler.Shutdown()
+
+	select {
+	case rr := <-done:
+		evts := decodeJSONEvents(t, rr.Body.Bytes())
+		assert.Equal(t, []string{"RUN_STARTED", "CUSTOM", "RUN_ERROR"}, jsonEventTypes(evts))
+		assert.Equal(t, ErrHandlerShutdown.Error(), evts[2]["message"])
+	case <-time.After(time.Second):
+		t.Fatal("run was not cancelled by Shutdown")
+	}
+	assert.ErrorIs(t, <-stopped, ErrHandlerShutdown)
+}
+
+func TestHandler_ClientDisconnect(t *testing.T) {
+	t.Run("channel source", func(t *testing.T) {
+		stopped := make(chan error, 1)
+		handler := New(Config{EventSource: blockingSource(stopped)})
+
+		reqCtx, cancel := context.WithCancel(context.Background())
+		body, _ := json.Marshal(RunAgentInput{ThreadID: "thread-1"})
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)).WithContext(reqCtx)
+
+		served := make(chan struct{})
+		go func() {
+			defer close(served)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-stopped:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			t.Fatal("source was not cancelled")
+		}
+		<-served
+	})
+
+	t.Run("iterator source stops after the handler returns", func(t *testing.T) {
+		var runCtx context.Context
+		handler := New(Config{EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			runCtx = ctx.Context
+			return textSeq("m1", "hi")
+		})})
+
+		runJSON(t, handler, RunAgentInput{ThreadID: "thread-1"})
+
+		require.NotNil(t, runCtx)
+		assert.Error(t, runCtx.Err())
+	})
+}
@@ -0,0 +1,187 @@
+package aguigo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrRunCancelled is the cancellation cause of a run stopped through a RunRegistry
+var ErrRunCancelled = errors.New("run cancelled")
+
+// ErrRunNotFound is returned when cancelling a run that is not active
+var ErrRunNotFound = errors.New("run not found")
+
+// RunInfo describes an active run
+type RunInfo struct {
+	ThreadID  string    `json:"threadId"`
+	RunID     string    `json:"runId"`
+	UserID    string    `json:"userId,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+type runKey struct {
+	threadID string
+	runID    string
+}
+
+type activeRun struct {
+	info   RunInfo
+	cancel context.CancelCauseFunc
+}
+
+// RunRegistry tracks the runs in progress so they can be stopped from
+// outside the streaming request, e.g. by a "stop generating" button. Share
+// one registry between a handler (Config.Runs or WithRunRegistry) and the
+// cancel endpoint it serves.
+//
+// Routes are relative to the mount point:
+//
+//	GET  /                          list active runs (?threadId= to filter)
+//	POST /{threadId}/{runId}/cancel cancel a run
+//
+// The endpoint only sees runs without a user or owned by the caller, who is
+// resolved from X-User-ID unless WithUserID says otherwise; the Go API is
+// not scoped.
+type RunRegistry struct {
+	mu     sync.Mutex
+	runs   map[runKey]*activeRun
+	userID UserIDFunc
+	mux    *http.ServeMux
+}
+
+// NewRunRegistry creates an empty run registry
+func NewRunRegistry() *RunRegistry {
+	reg := &RunRegistry{
+		runs:   make(map[runKey]*activeRun),
+		userID: UserIDHeader("X-User-ID"),
+		mux:    http.NewServeMux(),
+	}
+
+	reg.mux.HandleFunc("GET /{$}", reg.handleList)
+	reg.mux.HandleFunc("POST /{threadID}/{runID}/cancel", reg.handleCancel)
+
+	return reg
+}
+
+// WithUserID resolves the caller of the HTTP endpoint with fn. It should
+// match the user the handlers recording runs in reg resolve.
+func (reg *RunRegistry) WithUserID(fn UserIDFunc) *RunRegistry {
+	reg.userID = fn
+	return reg
+}
+
+// Cancel stops a run. Its stream ends with RUN_ERROR "run cancelled".
+func (reg *RunRegistry) Cancel(threadID, runID string) error {
+	reg.mu.Lock()
+	run, ok := reg.runs[runKey{threadID, runID}]
+	reg.mu.Unlock()
+
+	if !ok {
+		return ErrRunNotFound
+	}
+	run.cancel(ErrRunCancelled)
+	return nil
+}
+
+// Get returns an active run
+func (reg *RunRegistry) Get(threadID, runID string) (RunInfo, bool) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	run, ok := reg.runs[runKey{threadID, runID}]
+	if !ok {
+		return RunInfo{}, false
+	}
+	return run.info, true
+}
+
+// Active returns the active runs, oldest first. An empty threadID returns
+// the runs of all threads.
+func (reg *RunRegistry) Active(threadID string) []RunInfo {
+	reg.mu.Lock()
+	runs := make([]RunInfo, 0, len(reg.runs))
+	for _, run := range reg.runs {
+		if threadID == "" || run.info.ThreadID == threadID {
+			runs = append(runs, run.info)
+		}
+	}
+	reg.mu.Unlock()
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.Before(runs[j].StartedAt) })
+	return runs
+}
+
+// register adds a run and returns the function that removes it again
+func (reg *RunRegistry) register(info RunInfo, cancel context.CancelCauseFunc) func() {
+	key := runKey{info.ThreadID, info.RunID}
+	run := &activeRun{info: info, cancel: cancel}
+
+	reg.mu.Lock()
+	reg.runs[key] = run
+	reg.mu.Unlock()
+
+	return func() {
+		reg.mu.Lock()
+		defer reg.mu.Unlock()
+		// A later run reusing the IDs must not be removed by this one
+		if reg.runs[key] == run {
+			delete(reg.runs, key)
+		}
+	}
+}
+
+// ServeHTTP handles run registry requests
+func (reg *RunRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization, X-User-ID")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	reg.mux.ServeHTTP(w, r)
+}
+
+// handleList returns the caller's active runs
+func (reg *RunRegistry) handleList(w http.ResponseWriter, r *http.Request) {
+	runs := []RunInfo{}
+	for _, run := range reg.Active(r.URL.Query().Get("threadId")) {
+		if ownsRun(reg.userID, r, run) {
+			runs = append(runs, run)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"runs": runs})
+}
+
+// handleCancel cancels one of the caller's runs
+func (reg *RunRegistry) handleCancel(w http.ResponseWriter, r *http.Request) {
+	threadID, runID := r.PathValue("threadID"), r.PathValue("runID")
+
+	run, ok := reg.Get(threadID, runID)
+	if !ok || !ownsRun(reg.userID, r, run) {
+		http.Error(w, ErrRunNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	if err := reg.Cancel(threadID, runID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"threadId":  threadID,
+		"runId":     runID,
+		"cancelled": true,
+	})
+}
+
+// ownsRun reports whether the request may see a run
+func ownsRun(userID UserIDFunc, r *http.Request, run RunInfo) bool {
+	return run.UserID == "" || run.UserID == userID(r)
+}
@@ -0,0 +1,188 @@
+package aguigo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// waitForRun polls the registry until the run is active
+func waitForRun(t *testing.T, reg *RunRegistry, threadID, runID string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		_, ok := reg.Get(threadID, runID)
+		return ok
+	}, time.Second, 5*time.Millisecond)
+}
+
+// serveAsync serves req in the background and returns the recorder once done
+func serveAsync(handler http.Handler, req *http.Request) <-chan *httptest.ResponseRecorder {
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		done <- rr
+	}()
+	return done
+}
+
+func TestRunRegistry(t *testing.T) {
+	reg := NewRunRegistry()
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	unregister := reg.register(RunInfo{ThreadID: "thread-1", RunID: "run-1", StartedAt: time.Now()}, cancel)
+	reg.register(RunInfo{ThreadID: "thread-2", RunID: "run-2", StartedAt: time.Now()}, func(error) {})
+
+	assert.Len(t, reg.Active(""), 2)
+	assert.Len(t, reg.Active("thread-1"), 1)
+
+	require.NoError(t, reg.Cancel("thread-1", "run-1"))
+	assert.ErrorIs(t, context.Cause(ctx), ErrRunCancelled)
+	assert.ErrorIs(t, reg.Cancel("thread-1", "missing"), ErrRunNotFound)
+
+	unregister()
+	_, ok := reg.Get("thread-1", "run-1")
+	assert.False(t, ok)
+}
+
+func TestRunRegistry_ServeHTTP(t *testing.T) {
+	reg := NewRunRegistry()
+	reg.register(RunInfo{ThreadID: "thread-1", RunID: "run-1", UserID: "alice", StartedAt: time.Now()}, func(error) {})
+	reg.register(RunInfo{ThreadID: "thread-1", RunID: "run-2", StartedAt: time.Now()}, func(error) {})
+
+	do := func(method, path, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if userID != "" {
+			req.Header.Set("X-User-ID", userID)
+		}
+		rr := httptest.NewRecorder()
+		reg.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("lists the caller's runs", func(t *testing.T) {
+		rr := do(http.MethodGet, "/?threadId=thread-1", "bob")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Runs []RunInfo `json:"runs"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Runs, 1)
+		assert.Equal(t, "run-2", resp.Runs[0].RunID)
+	})
+
+	t.Run("cancels a run", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, do(http.MethodPost, "/thread-1/run-1/cancel", "alice").Code)
+	})
+
+	t.Run("hides other users' runs", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/thread-1/run-1/cancel", "bob").Code)
+	})
+
+	t.Run("unknown run", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/thread-1/missing/cancel", "").Code)
+	})
+}
+
+func TestHandler_CancelRun(t *testing.T) {
+	reg := NewRunRegistry()
+	handler := New(Config{
+		Runs: reg,
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return func(yield func(events.Event, error) bool) {
+				if !yield(events.NewCustomEvent("working"), nil) {
+					return
+				}
+				<-ctx.Done()
+			}
+		}),
+	})
+
+	body, _ := json.Marshal(RunAgentInput{ThreadID: "thread-1", RunID: "run-1"})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Accept", "application/json")
+	done := serveAsync(handler, req)
+
+	waitForRun(t, reg, "thread-1", "run-1")
+	require.NoError(t, reg.Cancel("thread-1", "run-1"))
+
+	rr := <-done
+	evts := decodeJSONEvents(t, rr.Body.Bytes())
+	assert.Equal(t, []string{"RUN_STARTED", "CUSTOM", "RUN_ERROR"}, jsonEventTypes(evts))
+	assert.Equal(t, ErrRunCancelled.Error(), evts[2]["message"])
+
+	_, ok := reg.Get("thread-1", "run-1")
+	assert.False(t, ok)
+}
+
+func TestADKHandler_CancelRun(t *testing.T) {
+	reg := NewRunRegistry()
+
+	// The agent streams part of an answer and then waits to be cancelled
+	streamed := make(chan struct{})
+	ag, err := agent.New(agent.Config{
+		Name: "test_agent",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				for _, chunk := range []string{"The answer", " is"} {
+					evt := session.NewEvent(ctx.InvocationID())
+					evt.Author = "test_agent"
+					evt.LLMResponse = model.LLMResponse{
+						Content: genai.NewContentFromText(chunk, genai.RoleModel),
+						Partial: true,
+					}
+					if !yield(evt, nil) {
+						return
+					}
+				}
+				close(streamed)
+				<-ctx.Done()
+				yield(nil, ctx.Err())
+			}
+		},
+	})
+	require.NoError(t, err)
+
+	sessions := session.InMemoryService()
+	handler, err := NewADKHandler(ag, sessions, "test-app", WithRunRegistry(reg))
+	require.NoError(t, err)
+
+	body := `{"threadId":"thread-1","runId":"run-1","messages":[{"id":"m1","role":"user","content":"Hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	req.Header.Set("Accept", "application/json")
+	done := serveAsync(handler, req)
+
+	<-streamed
+	require.NoError(t, reg.Cancel("thread-1", "run-1"))
+
+	rr := <-done
+	evts := decodeJSONEvents(t, rr.Body.Bytes())
+	types := jsonEventTypes(evts)
+	assert.Equal(t, []string{"TEXT_MESSAGE_END", "RUN_ERROR"}, types[len(types)-2:])
+	assert.Equal(t, ErrRunCancelled.Error(), evts[len(evts)-1]["message"])
+
+	resp, err := sessions.Get(context.Background(), &session.GetRequest{AppName: "test-app", UserID: "default-user", SessionID: "thread-1"})
+	require.NoError(t, err)
+	var last *session.Event
+	for evt := range resp.Session.Events().All() {
+		last = evt
+	}
+	require.NotNil(t, last)
+	assert.True(t, last.Interrupted)
+	assert.Equal(t, "test_agent", last.Author)
+	assert.Equal(t, "The answer is", last.Content.Parts[0].Text)
+}
@@ -0,0 +1,66 @@
+package aguigo
+
+import (
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// runSnapshot follows a run's events and can describe where the run stands
+// as a MESSAGES_SNAPSHOT and STATE_SNAPSHOT, so a late subscriber can catch
+// up without the full event history
+type runSnapshot struct {
+	started events.Event
+	ended   events.Event
+	reducer *Reducer
+}
+
+func newRunSnapshot() *runSnapshot {
+	return &runSnapshot{reducer: NewReducer()}
+}
+
+// seed adds the conversation history and state the run started from
+func (s *runSnapshot) seed(messages []Message, state any) {
+	s.reducer.Seed(messages)
+	if state != nil {
+		s.reducer.SeedState(state)
+	}
+}
+
+// apply updates the snapshot with one event. Events the reducer rejects are
+// left out of the snapshot; subscribers still receive them live.
+func (s *runSnapshot) apply(evt events.Event) {
+	switch evt.(type) {
+	case *events.RunStartedEvent:
+		s.started = evt
+	case *events.RunFinishedEvent, *events.RunErrorEvent:
+		s.ended = evt
+	}
+	s.reducer.Apply(evt)
+}
+
+// events returns the events that bring a new subscriber up to date. With
+// includeStart the snapshot opens with the run's RUN_STARTED; a terminal
+// event is included once the run has ended. While the run is going,
+// messages and tool calls still streaming are left out of the messages
+// snapshot and started again after it, so their live events fit.
+func (s *runSnapshot) events(includeStart bool) []events.Event {
+	var out []events.Event
+	if includeStart && s.started != nil {
+		out = append(out, s.started)
+	}
+
+	messages, reopen := s.reducer.resume()
+	if s.ended != nil {
+		messages, reopen = s.reducer.Messages(), nil
+	}
+
+	out = append(out, events.NewMessagesSnapshotEvent(messages))
+	if state := s.reducer.State(); state != nil {
+		out = append(out, events.NewStateSnapshotEvent(state))
+	}
+	out = append(out, reopen...)
+
+	if s.ended != nil {
+		out = append(out, s.ended)
+	}
+	return out
+}
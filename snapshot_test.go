@@ -0,0 +1,56 @@
+package aguigo
+
+import (
+	"testing"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSnapshot(t *testing.T) {
+	snap := newRunSnapshot()
+	for _, evt := range []events.Event{
+		events.NewRunStartedEvent("thread-1", "run-1"),
+		events.NewTextMessageStartEvent("m1", events.WithRole("assistant")),
+		events.NewTextMessageContentEvent("m1", "Let me check"),
+		events.NewTextMessageEndEvent("m1"),
+		events.NewToolCallStartEvent("call-1", "get_weather", events.WithParentMessageID("m1")),
+		events.NewToolCallArgsEvent("call-1", `{"city":`),
+		events.NewToolCallArgsEvent("call-1", `"Paris"}`),
+		events.NewToolCallEndEvent("call-1"),
+		events.NewToolCallResultEvent("m2", "call-1", "Sunny"),
+		events.NewStateSnapshotEvent(map[string]any{"todos": []string{"a"}}),
+		events.NewStateDeltaEvent([]events.JSONPatchOperation{{Op: "add", Path: "/todos/-", Value: "b"}}),
+		events.NewRunFinishedEvent("thread-1", "run-1"),
+	} {
+		snap.apply(evt)
+	}
+
+	evts := snap.events(true)
+	require.Len(t, evts, 4)
+	assert.Equal(t, events.EventTypeRunStarted, evts[0].Type())
+	assert.Equal(t, events.EventTypeRunFinished, evts[3].Type())
+
+	messages := evts[1].(*events.MessagesSnapshotEvent).Messages
+	require.Len(t, messages, 2)
+	assert.Equal(t, "Let me check", *messages[0].Content)
+	require.Len(t, messages[0].ToolCalls, 1)
+	assert.Equal(t, "get_weather", messages[0].ToolCalls[0].Function.Name)
+	assert.Equal(t, `{"city":"Paris"}`, messages[0].ToolCalls[0].Function.Arguments)
+	assert.Equal(t, "tool", messages[1].Role)
+	assert.Equal(t, "call-1", *messages[1].ToolCallID)
+	assert.Equal(t, "Sunny", *messages[1].Content)
+
+	state := evts[2].(*events.StateSnapshotEvent).Snapshot
+	assert.Equal(t, map[string]any{"todos": []any{"a", "b"}}, state)
+
+	t.Run("a messages snapshot replaces the conversation", func(t *testing.T) {
+		content := "Start over"
+		snap.apply(events.NewMessagesSnapshotEvent([]events.Message{{ID: "m9", Role: "user", Content: &content}}))
+
+		messages := snap.reducer.Messages()
+		require.Len(t, messages, 1)
+		assert.Equal(t, "m9", messages[0].ID)
+	})
+}
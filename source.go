@@ -0,0 +1,122 @@
+package aguigo
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// IterEventSource is an event source that yields its events from an
+// iterator. Unlike the channel form it can report failures, which the handler
+// turns into RUN_ERROR, and it needs no goroutine: when the client goes away
+// the handler stops ranging and the producer's yield returns false.
+type IterEventSource interface {
+	Events(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error]
+}
+
+// SeqFunc is an event source written as a function returning an iterator. It
+// satisfies both EventSource and IterEventSource; the handler uses the
+// iterator form.
+type SeqFunc func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error]
+
+// Events implements IterEventSource
+func (f SeqFunc) Events(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+	return f(ctx, input)
+}
+
+// Run implements EventSource
+func (f SeqFunc) Run(ctx HandlerContext, input RunAgentInput) <-chan events.Event {
+	return SeqToChan(ctx, f(ctx, input))
+}
+
+// IterSource adapts a channel-based EventSource to an IterEventSource. If src
+// already implements IterEventSource it is returned as is.
+func IterSource(src EventSource) IterEventSource {
+	if it, ok := src.(IterEventSource); ok {
+		return it
+	}
+	return SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+		return ChanToSeq(src.Run(ctx, input))
+	})
+}
+
+// SeqToChan runs seq in a goroutine and sends its events on the returned
+// channel. An error is sent as RUN_ERROR and ends the stream. The producer is
+// stopped when the run context is cancelled, so an abandoned channel does not
+// leak the goroutine.
+func SeqToChan(ctx HandlerContext, seq iter.Seq2[events.Event, error]) <-chan events.Event {
+	ch := make(chan events.Event)
+	send := func(evt events.Event) bool { return sendEvent(ctx, ch, evt) }
+
+	go func() {
+		defer close(ch)
+		for evt, err := range recoverSeq(seq) {
+			if err != nil {
+				send(events.NewRunErrorEvent(err.Error(), events.WithRunID(ctx.RunID)))
+				return
+			}
+			if !send(evt) {
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// ChanToSeq yields the events received on ch. When the consumer stops early
+// the rest of the channel is drained in the background so the producer can
+// finish instead of blocking forever.
+func ChanToSeq(ch <-chan events.Event) iter.Seq2[events.Event, error] {
+	return func(yield func(events.Event, error) bool) {
+		if ch == nil {
+			return
+		}
+		for evt := range ch {
+			if !yield(evt, nil) {
+				go drain(ch)
+				return
+			}
+		}
+	}
+}
+
+// recoverSeq turns a panic in the producer into a final error
+func recoverSeq(seq iter.Seq2[events.Event, error]) iter.Seq2[events.Event, error] {
+	return func(yield func(events.Event, error) bool) {
+		// A panic raised by the consumer inside yield is not ours to recover
+		inYield := false
+		defer func() {
+			if r := recover(); r != nil {
+				if inYield {
+					panic(r)
+				}
+				yield(nil, fmt.Errorf("event source panicked: %v", r))
+			}
+		}()
+		for evt, err := range seq {
+			inYield = true
+			ok := yield(evt, err)
+			inYield = false
+			if !ok || err != nil {
+				return
+			}
+		}
+	}
+}
+
+func drain(ch <-chan events.Event) {
+	for range ch {
+	}
+}
+
+// sendEvent sends evt on ch unless the run is cancelled first
+func sendEvent(ctx HandlerContext, ch chan<- events.Event, evt events.Event) bool {
+	select {
+	case ch <- evt:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,130 @@
+package aguigo
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_IterEventSource(t *testing.T) {
+	input := RunAgentInput{ThreadID: "thread-1", RunID: "run-1"}
+
+	t.Run("streams iterator events", func(t *testing.T) {
+		handler := New(Config{EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return textSeq("m1", "a", "b")
+		})})
+
+		assert.Equal(t, []string{"RUN_STARTED", "TEXT_MESSAGE_START", "TEXT_MESSAGE_CONTENT", "TEXT_MESSAGE_CONTENT", "TEXT_MESSAGE_END", "RUN_FINISHED"},
+			jsonEventTypes(runJSON(t, handler, input)))
+	})
+
+	t.Run("error becomes RUN_ERROR", func(t *testing.T) {
+		handler := New(Config{EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return func(yield func(events.Event, error) bool) {
+				if !yield(events.NewCustomEvent("progress"), nil) {
+					return
+				}
+				yield(nil, errors.New("quota exceeded"))
+			}
+		})})
+
+		evts := runJSON(t, handler, input)
+
+		assert.Equal(t, []string{"RUN_STARTED", "CUSTOM", "RUN_ERROR"}, jsonEventTypes(evts))
+		assert.Equal(t, "quota exceeded", evts[2]["message"])
+	})
+
+	t.Run("panic becomes RUN_ERROR", func(t *testing.T) {
+		handler := New(Config{EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return func(yield func(events.Event, error) bool) {
+				panic("boom")
+			}
+		})})
+
+		evts := runJSON(t, handler, input)
+
+		assert.Equal(t, []string{"RUN_STARTED", "RUN_ERROR"}, jsonEventTypes(evts))
+		assert.Contains(t, evts[1]["message"], "boom")
+	})
+}
+
+func TestSeqToChan(t *testing.T) {
+	t.Run("forwards events and maps errors", func(t *testing.T) {
+		seq := func(yield func(events.Event, error) bool) {
+			if !yield(events.NewCustomEvent("first"), nil) {
+				return
+			}
+			yield(nil, errors.New("failed"))
+		}
+
+		var out []events.Event
+		for evt := range SeqToChan(HandlerContext{RunID: "run-1"}, seq) {
+			out = append(out, evt)
+		}
+
+		require.Equal(t, []events.EventType{events.EventTypeCustom, events.EventTypeRunError}, eventTypes(out))
+		runErr := out[1].(*events.RunErrorEvent)
+		assert.Equal(t, "failed", runErr.Message)
+		assert.Equal(t, "run-1", runErr.RunIDValue)
+	})
+
+	t.Run("stops the producer when the request is cancelled", func(t *testing.T) {
+		reqCtx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest("POST", "/", nil).WithContext(reqCtx)
+
+		stopped := make(chan struct{})
+		seq := func(yield func(events.Event, error) bool) {
+			defer close(stopped)
+			for yield(events.NewCustomEvent("tick"), nil) {
+			}
+		}
+
+		ch := SeqToChan(HandlerContext{Request: req}, seq)
+		<-ch
+		cancel()
+
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("producer was not stopped")
+		}
+	})
+}
+
+func TestChanToSeq(t *testing.T) {
+	t.Run("stopping early drains the producer", func(t *testing.T) {
+		finished := make(chan struct{})
+		src := StreamFunc(func(ctx HandlerContext, input RunAgentInput, emit func(events.Event)) error {
+			defer close(finished)
+			for i := 0; i < 10; i++ {
+				emit(events.NewCustomEvent("tick"))
+			}
+			return nil
+		})
+
+		for range IterSource(src).Events(HandlerContext{}, RunAgentInput{}) {
+			break
+		}
+
+		select {
+		case <-finished:
+		case <-time.After(time.Second):
+			t.Fatal("producer goroutine leaked")
+		}
+	})
+
+	t.Run("iterator sources are used directly", func(t *testing.T) {
+		src := SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return textSeq("m1")
+		})
+		_, ok := IterSource(src).(SeqFunc)
+		assert.True(t, ok)
+	})
+}
@@ -0,0 +1,528 @@
+package aguigo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+)
+
+// ValidationMode controls what a SequenceValidator does with a protocol violation
+type ValidationMode int
+
+const (
+	// ValidationStrict replaces the first violation with a RUN_ERROR and drops the rest of the stream
+	ValidationStrict ValidationMode = iota
+	// ValidationRepair fixes the stream by injecting missing START/END events and dropping invalid ones
+	ValidationRepair
+	// ValidationLog reports violations and passes every event through unchanged
+	ValidationLog
+)
+
+// SequenceErrorCode is the RUN_ERROR code emitted in strict mode
+const SequenceErrorCode = "INVALID_EVENT_SEQUENCE"
+
+// SequenceError describes a protocol violation in an event stream
+type SequenceError struct {
+	// Index is the position of the offending event in the input stream
+	Index int
+	// EventType is the type of the offending event, empty when the stream ended early
+	EventType events.EventType
+	// Reason explains which rule was broken
+	Reason string
+}
+
+func (e *SequenceError) Error() string {
+	if e.EventType == "" {
+		return fmt.Sprintf("invalid event sequence at end of stream (after %d events): %s", e.Index, e.Reason)
+	}
+	return fmt.Sprintf("invalid event sequence at event %d (%s): %s", e.Index, e.EventType, e.Reason)
+}
+
+type runPhase int
+
+const (
+	phaseNotStarted runPhase = iota
+	phaseRunning
+	phaseFinished
+)
+
+// SequenceValidator is a state machine that checks a single run's event
+// stream against the AG-UI protocol: one RUN_STARTED first, messages and
+// tool calls opened before use and closed once, and nothing after the run ends.
+type SequenceValidator struct {
+	mode   ValidationMode
+	logger Logger
+
+	threadID string
+	runID    string
+
+	index           int
+	phase           runPhase
+	failed          bool
+	openMessages    map[string]bool
+	closedMessages  map[string]bool
+	messageOrder    []string
+	openToolCalls   map[string]bool
+	closedToolCalls map[string]bool
+	toolCallOrder   []string
+	openSteps       map[string]bool
+	thinking        bool
+	thinkingMessage bool
+	violations      []*SequenceError
+}
+
+// NewSequenceValidator creates a validator for one run's event stream
+func NewSequenceValidator(mode ValidationMode) *SequenceValidator {
+	return &SequenceValidator{
+		mode:            mode,
+		logger:          defaultLogger{},
+		openMessages:    make(map[string]bool),
+		closedMessages:  make(map[string]bool),
+		openToolCalls:   make(map[string]bool),
+		closedToolCalls: make(map[string]bool),
+		openSteps:       make(map[string]bool),
+	}
+}
+
+// WithLogger sets the logger violations are reported to
+func (v *SequenceValidator) WithLogger(logger Logger) *SequenceValidator {
+	if logger != nil {
+		v.logger = logger
+	}
+	return v
+}
+
+// WithRunIDs sets the IDs used for events injected while repairing
+func (v *SequenceValidator) WithRunIDs(threadID, runID string) *SequenceValidator {
+	v.threadID = threadID
+	v.runID = runID
+	return v
+}
+
+// Err returns the first violation seen, or nil
+func (v *SequenceValidator) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return v.violations[0]
+}
+
+// Violations returns every violation seen so far
+func (v *SequenceValidator) Violations() []*SequenceError {
+	return v.violations
+}
+
+// Process validates one event and returns the events to emit in its place
+func (v *SequenceValidator) Process(evt events.Event) []events.Event {
+	defer func() { v.index++ }()
+
+	if v.failed {
+		return nil
+	}
+
+	var result []events.Event
+	emit := func(evts ...events.Event) { result = append(result, evts...) }
+
+	// violation records a broken rule and returns what to emit instead of evt:
+	// a RUN_ERROR in strict mode, the repair events in repair mode, or evt
+	// itself in log mode. A run that already ended gets no RUN_ERROR, which
+	// would itself follow the end.
+	ended := v.phase == phaseFinished
+	violation := func(reason string, repair ...events.Event) []events.Event {
+		err := v.report(evt.Type(), reason)
+		switch v.mode {
+		case ValidationStrict:
+			v.failed = true
+			if ended {
+				return result
+			}
+			return append(result, v.strictError(err))
+		case ValidationRepair:
+			return append(result, repair...)
+		default:
+			return append(result, evt)
+		}
+	}
+
+	if v.phase == phaseFinished {
+		return violation("event after the run finished")
+	}
+
+	if v.phase == phaseNotStarted {
+		switch e := evt.(type) {
+		case *events.RunStartedEvent:
+			v.phase = phaseRunning
+			if v.threadID == "" {
+				v.threadID = e.ThreadIDValue
+			}
+			if v.runID == "" {
+				v.runID = e.RunIDValue
+			}
+			return []events.Event{evt}
+		case *events.RunErrorEvent:
+			v.phase = phaseFinished
+			return []events.Event{evt}
+		}
+
+		if v.mode == ValidationStrict {
+			return violation("first event must be RUN_STARTED")
+		}
+		v.report(evt.Type(), "first event must be RUN_STARTED")
+		v.phase = phaseRunning
+		if v.mode == ValidationRepair {
+			emit(events.NewRunStartedEvent(v.ids()))
+		}
+		// Fall through so the event is validated against the started run
+	}
+
+	switch e := evt.(type) {
+	case *events.RunStartedEvent:
+		return violation("duplicate RUN_STARTED")
+
+	case *events.RunFinishedEvent:
+		if open := v.openItems(); len(open) > 0 {
+			v.phase = phaseFinished
+			return violation("RUN_FINISHED with unclosed "+open[0], append(v.closeAll(), evt)...)
+		}
+		v.phase = phaseFinished
+
+	case *events.RunErrorEvent:
+		v.phase = phaseFinished
+
+	case *events.TextMessageStartEvent:
+		if v.openMessages[e.MessageID] {
+			return violation(fmt.Sprintf("TEXT_MESSAGE_START for message %q that is already open", e.MessageID))
+		}
+		if v.closedMessages[e.MessageID] {
+			return violation(fmt.Sprintf("TEXT_MESSAGE_START reuses ended message %q", e.MessageID))
+		}
+		v.openMessage(e.MessageID)
+
+	case *events.TextMessageContentEvent:
+		if !v.openMessages[e.MessageID] {
+			if v.closedMessages[e.MessageID] {
+				return violation(fmt.Sprintf("TEXT_MESSAGE_CONTENT after TEXT_MESSAGE_END for message %q", e.MessageID))
+			}
+			v.openMessage(e.MessageID)
+			return violation(fmt.Sprintf("TEXT_MESSAGE_CONTENT before TEXT_MESSAGE_START for message %q", e.MessageID),
+				events.NewTextMessageStartEvent(e.MessageID, events.WithRole("assistant")), evt)
+		}
+
+	case *events.TextMessageEndEvent:
+		if !v.openMessages[e.MessageID] {
+			return violation(fmt.Sprintf("TEXT_MESSAGE_END for message %q that is not open", e.MessageID))
+		}
+		v.closeMessage(e.MessageID)
+
+	case *events.ToolCallStartEvent:
+		if v.openToolCalls[e.ToolCallID] || v.closedToolCalls[e.ToolCallID] {
+			return violation(fmt.Sprintf("duplicate TOOL_CALL_START for tool call %q", e.ToolCallID))
+		}
+		v.openToolCall(e.ToolCallID)
+
+	case *events.ToolCallArgsEvent:
+		if !v.openToolCalls[e.ToolCallID] {
+			if v.closedToolCalls[e.ToolCallID] {
+				return violation(fmt.Sprintf("TOOL_CALL_ARGS after TOOL_CALL_END for tool call %q", e.ToolCallID))
+			}
+			return violation(fmt.Sprintf("TOOL_CALL_ARGS before TOOL_CALL_START for tool call %q", e.ToolCallID))
+		}
+
+	case *events.ToolCallEndEvent:
+		if !v.openToolCalls[e.ToolCallID] {
+			return violation(fmt.Sprintf("TOOL_CALL_END for tool call %q that is not open", e.ToolCallID))
+		}
+		v.closeToolCall(e.ToolCallID)
+
+	case *events.ToolCallResultEvent:
+		if v.openToolCalls[e.ToolCallID] {
+			v.closeToolCall(e.ToolCallID)
+			return violation(fmt.Sprintf("TOOL_CALL_RESULT before TOOL_CALL_END for tool call %q", e.ToolCallID),
+				events.NewToolCallEndEvent(e.ToolCallID), evt)
+		}
+
+	case *events.MessagesSnapshotEvent:
+		v.replaceMessages(e.Messages)
+
+	case *events.StepStartedEvent:
+		if v.openSteps[e.StepName] {
+			return violation(fmt.Sprintf("STEP_STARTED for step %q that is already running", e.StepName))
+		}
+		v.openSteps[e.StepName] = true
+
+	case *events.StepFinishedEvent:
+		if !v.openSteps[e.StepName] {
+			return violation(fmt.Sprintf("STEP_FINISHED for step %q that was not started", e.StepName))
+		}
+		delete(v.openSteps, e.StepName)
+
+	case *events.ThinkingStartEvent:
+		if v.thinking {
+			return violation("THINKING_START while already thinking")
+		}
+		v.thinking = true
+
+	case *events.ThinkingTextMessageStartEvent:
+		if v.thinkingMessage {
+			return violation("THINKING_TEXT_MESSAGE_START while a thinking message is open")
+		}
+		if !v.thinking {
+			v.thinking, v.thinkingMessage = true, true
+			return violation("THINKING_TEXT_MESSAGE_START outside THINKING_START/THINKING_END",
+				events.NewThinkingStartEvent(), evt)
+		}
+		v.thinkingMessage = true
+
+	case *events.ThinkingTextMessageContentEvent:
+		if !v.thinkingMessage {
+			return violation("THINKING_TEXT_MESSAGE_CONTENT outside a thinking message")
+		}
+
+	case *events.ThinkingTextMessageEndEvent:
+		if !v.thinkingMessage {
+			return violation("THINKING_TEXT_MESSAGE_END without THINKING_TEXT_MESSAGE_START")
+		}
+		v.thinkingMessage = false
+
+	case *events.ThinkingEndEvent:
+		if !v.thinking {
+			return violation("THINKING_END without THINKING_START")
+		}
+		if v.thinkingMessage {
+			v.thinking, v.thinkingMessage = false, false
+			return violation("THINKING_END with an open thinking message",
+				events.NewThinkingTextMessageEndEvent(), evt)
+		}
+		v.thinking = false
+	}
+
+	emit(evt)
+	return result
+}
+
+// Close checks the end of the stream and returns the events needed to
+// complete it in repair mode. Streams must end with RUN_FINISHED or RUN_ERROR.
+func (v *SequenceValidator) Close() []events.Event {
+	if v.failed || v.phase == phaseFinished {
+		return nil
+	}
+
+	reason := "stream ended without RUN_FINISHED"
+	if v.phase == phaseNotStarted {
+		if v.index == 0 {
+			// Nothing was sent at all; there is no run to complete
+			return nil
+		}
+		reason = "stream ended without RUN_STARTED"
+	} else if open := v.openItems(); len(open) > 0 {
+		reason = "stream ended with unclosed " + open[0]
+	}
+
+	err := v.report("", reason)
+
+	switch v.mode {
+	case ValidationStrict:
+		v.failed = true
+		return []events.Event{v.strictError(err)}
+	case ValidationRepair:
+		v.phase = phaseFinished
+		return append(v.closeAll(), events.NewRunFinishedEvent(v.ids()))
+	}
+	return nil
+}
+
+// Wrap validates a channel of events, forwarding the validated stream until
+// ctx is done. The input is always drained, also after ctx is done, so the
+// producer never blocks on a stopped stream.
+func (v *SequenceValidator) Wrap(ctx context.Context, in <-chan events.Event) <-chan events.Event {
+	out := make(chan events.Event)
+	go func() {
+		defer close(out)
+		send := func(evts []events.Event) {
+			for _, o := range evts {
+				select {
+				case out <- o:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		for evt := range in {
+			send(v.Process(evt))
+		}
+		send(v.Close())
+	}()
+	return out
+}
+
+// ValidateEvents checks a complete event sequence and returns the first violation
+func ValidateEvents(evts []events.Event) error {
+	v := NewSequenceValidator(ValidationStrict)
+	for _, evt := range evts {
+		v.Process(evt)
+	}
+	v.Close()
+	return v.Err()
+}
+
+// report records and logs a violation at the current position
+func (v *SequenceValidator) report(eventType events.EventType, reason string) *SequenceError {
+	err := &SequenceError{Index: v.index, EventType: eventType, Reason: reason}
+	v.violations = append(v.violations, err)
+	v.logger.Printf("[AG-UI] %v", err)
+	return err
+}
+
+func (v *SequenceValidator) strictError(err *SequenceError) events.Event {
+	return events.NewRunErrorEvent(err.Error(), events.WithErrorCode(SequenceErrorCode), events.WithRunID(v.runID))
+}
+
+func (v *SequenceValidator) ids() (string, string) {
+	threadID, runID := v.threadID, v.runID
+	if threadID == "" {
+		threadID = events.GenerateThreadID()
+		v.threadID = threadID
+	}
+	if runID == "" {
+		runID = events.GenerateRunID()
+		v.runID = runID
+	}
+	return threadID, runID
+}
+
+func (v *SequenceValidator) openMessage(id string) {
+	v.openMessages[id] = true
+	v.messageOrder = append(v.messageOrder, id)
+}
+
+func (v *SequenceValidator) closeMessage(id string) {
+	delete(v.openMessages, id)
+	v.closedMessages[id] = true
+}
+
+func (v *SequenceValidator) openToolCall(id string) {
+	v.openToolCalls[id] = true
+	v.toolCallOrder = append(v.toolCallOrder, id)
+}
+
+func (v *SequenceValidator) closeToolCall(id string) {
+	delete(v.openToolCalls, id)
+	v.closedToolCalls[id] = true
+}
+
+// replaceMessages forgets the messages and tool calls a MESSAGES_SNAPSHOT
+// leaves out. The snapshot replaces the conversation, so they may be
+// started again, as a late subscriber's catch-up does.
+func (v *SequenceValidator) replaceMessages(snapshot []events.Message) {
+	kept := make(map[string]bool)
+	for _, m := range snapshot {
+		kept[m.ID] = true
+		for _, call := range m.ToolCalls {
+			kept[call.ID] = true
+		}
+	}
+	for _, ids := range []map[string]bool{v.openMessages, v.closedMessages, v.openToolCalls, v.closedToolCalls} {
+		for id := range ids {
+			if !kept[id] {
+				delete(ids, id)
+			}
+		}
+	}
+}
+
+// openItems describes whatever is still open, in the order it was opened
+func (v *SequenceValidator) openItems() []string {
+	var open []string
+	for _, id := range v.messageOrder {
+		if v.openMessages[id] {
+			open = append(open, fmt.Sprintf("message %q", id))
+		}
+	}
+	for _, id := range v.toolCallOrder {
+		if v.openToolCalls[id] {
+			open = append(open, fmt.Sprintf("tool call %q", id))
+		}
+	}
+	if v.thinking {
+		open = append(open, "thinking block")
+	}
+	return open
+}
+
+// closeAll emits END events for everything still open
+func (v *SequenceValidator) closeAll() []events.Event {
+	var result []events.Event
+	if v.thinkingMessage {
+		result = append(result, events.NewThinkingTextMessageEndEvent())
+		v.thinkingMessage = false
+	}
+	if v.thinking {
+		result = append(result, events.NewThinkingEndEvent())
+		v.thinking = false
+	}
+	for _, id := range v.messageOrder {
+		if v.openMessages[id] {
+			result = append(result, events.NewTextMessageEndEvent(id))
+			v.closeMessage(id)
+		}
+	}
+	for _, id := range v.toolCallOrder {
+		if v.openToolCalls[id] {
+			result = append(result, events.NewToolCallEndEvent(id))
+			v.closeToolCall(id)
+		}
+	}
+	return result
+}
+
+// validationInterceptor runs a SequenceValidator per run inside a handler
+type validationInterceptor struct {
+	mode   ValidationMode
+	logger Logger
+
+	mu         sync.Mutex
+	validators map[string]*SequenceValidator
+}
+
+// NewValidationInterceptor returns an interceptor that validates every run's
+// outgoing stream. Add it last so it sees the events exactly as written.
+func NewValidationInterceptor(mode ValidationMode, logger Logger) EventInterceptor {
+	if logger == nil {
+		logger = defaultLogger{}
+	}
+	return &validationInterceptor{
+		mode:       mode,
+		logger:     logger,
+		validators: make(map[string]*SequenceValidator),
+	}
+}
+
+// Intercept implements EventInterceptor
+func (i *validationInterceptor) Intercept(ctx HandlerContext, evt events.Event) []events.Event {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	key := ctx.ThreadID + "/" + ctx.RunID
+	v := i.validators[key]
+	if v == nil {
+		v = NewSequenceValidator(i.mode).WithLogger(i.logger).WithRunIDs(ctx.ThreadID, ctx.RunID)
+		i.validators[key] = v
+	}
+	return v.Process(evt)
+}
+
+// Flush implements EventFlusher, completing the run's stream
+func (i *validationInterceptor) Flush(ctx HandlerContext) []events.Event {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	key := ctx.ThreadID + "/" + ctx.RunID
+	v := i.validators[key]
+	if v == nil {
+		return nil
+	}
+	delete(i.validators, key)
+	return v.Close()
+}
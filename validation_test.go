@@ -0,0 +1,306 @@
+package aguigo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestValidateEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []events.Event
+		reason string
+	}{
+		{
+			name: "valid sequence",
+			events: []events.Event{
+				events.NewRunStartedEvent("t", "r"),
+				events.NewTextMessageStartEvent("m1"),
+				events.NewTextMessageContentEvent("m1", "hi"),
+				events.NewTextMessageEndEvent("m1"),
+				events.NewToolCallStartEvent("c1", "search"),
+				events.NewToolCallArgsEvent("c1", "{}"),
+				events.NewToolCallEndEvent("c1"),
+				events.NewToolCallResultEvent("m2", "c1", "ok"),
+				events.NewRunFinishedEvent("t", "r"),
+			},
+		},
+		{
+			name: "message restarted after a snapshot that leaves it out",
+			events: []events.Event{
+				events.NewRunStartedEvent("t", "r"),
+				events.NewTextMessageStartEvent("m1"),
+				events.NewTextMessageEndEvent("m1"),
+				events.NewMessagesSnapshotEvent(nil),
+				events.NewTextMessageStartEvent("m1"),
+				events.NewTextMessageEndEvent("m1"),
+				events.NewRunFinishedEvent("t", "r"),
+			},
+		},
+		{
+			name: "message restarted after a snapshot that keeps it",
+			events: []events.Event{
+				events.NewRunStartedEvent("t", "r"),
+				events.NewTextMessageStartEvent("m1"),
+				events.NewTextMessageEndEvent("m1"),
+				events.NewMessagesSnapshotEvent([]events.Message{{ID: "m1", Role: "assistant"}}),
+				events.NewTextMessageStartEvent("m1"),
+			},
+			reason: `TEXT_MESSAGE_START reuses ended message "m1"`,
+		},
+		{
+			name: "content after end",
+			events: []events.Event{
+				events.NewRunStartedEvent("t", "r"),
+				events.NewTextMessageStartEvent("m1"),
+				events.NewTextMessageEndEvent("m1"),
+				events.NewTextMessageContentEvent("m1", "late"),
+			},
+			reason: `TEXT_MESSAGE_CONTENT after TEXT_MESSAGE_END for message "m1"`,
+		},
+		{
+			name: "args before start",
+			events: []events.Event{
+				events.NewRunStartedEvent("t", "r"),
+				events.NewToolCallArgsEvent("c1", "{}"),
+			},
+			reason: `TOOL_CALL_ARGS before TOOL_CALL_START for tool call "c1"`,
+		},
+		{
+			name: "duplicate run started",
+			events: []events.Event{
+				events.NewRunStartedEvent("t", "r"),
+				events.NewRunStartedEvent("t", "r"),
+			},
+			reason: "duplicate RUN_STARTED",
+		},
+		{
+			name: "event after run finished",
+			events: []events.Event{
+				events.NewRunStartedEvent("t", "r"),
+				events.NewRunFinishedEvent("t", "r"),
+				events.NewCustomEvent("late"),
+			},
+			reason: "event after the run finished",
+		},
+		{
+			name: "missing run started",
+			events: []events.Event{
+				events.NewTextMessageStartEvent("m1"),
+			},
+			reason: "first event must be RUN_STARTED",
+		},
+		{
+			name: "missing run finished",
+			events: []events.Event{
+				events.NewRunStartedEvent("t", "r"),
+			},
+			reason: "stream ended without RUN_FINISHED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEvents(tt.events)
+			if tt.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var seqErr *SequenceError
+			require.ErrorAs(t, err, &seqErr)
+			assert.Equal(t, tt.reason, seqErr.Reason)
+		})
+	}
+}
+
+func TestSequenceValidator_Strict(t *testing.T) {
+	v := NewSequenceValidator(ValidationStrict)
+
+	assert.Len(t, v.Process(events.NewRunStartedEvent("t", "r")), 1)
+
+	out := v.Process(events.NewTextMessageEndEvent("m1"))
+	require.Len(t, out, 1)
+	runErr, ok := out[0].(*events.RunErrorEvent)
+	require.True(t, ok)
+	require.NotNil(t, runErr.Code)
+	assert.Equal(t, SequenceErrorCode, *runErr.Code)
+	assert.Equal(t, "r", runErr.RunIDValue)
+
+	// Everything after the first violation is dropped
+	assert.Empty(t, v.Process(events.NewRunFinishedEvent("t", "r")))
+	assert.Empty(t, v.Close())
+	assert.EqualError(t, v.Err(), `invalid event sequence at event 1 (TEXT_MESSAGE_END): TEXT_MESSAGE_END for message "m1" that is not open`)
+}
+
+func TestSequenceValidator_Repair(t *testing.T) {
+	t.Run("injects missing start and end events", func(t *testing.T) {
+		v := NewSequenceValidator(ValidationRepair).WithRunIDs("t", "r")
+
+		var out []events.Event
+		out = append(out, v.Process(events.NewTextMessageContentEvent("m1", "hi"))...)
+		out = append(out, v.Process(events.NewToolCallStartEvent("c1", "search"))...)
+		out = append(out, v.Process(events.NewToolCallResultEvent("m2", "c1", "ok"))...)
+		out = append(out, v.Close()...)
+
+		assert.Equal(t, []events.EventType{
+			events.EventTypeRunStarted,
+			events.EventTypeTextMessageStart,
+			events.EventTypeTextMessageContent,
+			events.EventTypeToolCallStart,
+			events.EventTypeToolCallEnd,
+			events.EventTypeToolCallResult,
+			events.EventTypeTextMessageEnd,
+			events.EventTypeRunFinished,
+		}, eventTypes(out))
+		assert.NoError(t, ValidateEvents(out))
+		assert.Len(t, v.Violations(), 4)
+	})
+
+	t.Run("drops invalid events", func(t *testing.T) {
+		v := NewSequenceValidator(ValidationRepair)
+
+		var out []events.Event
+		out = append(out, v.Process(events.NewRunStartedEvent("t", "r"))...)
+		out = append(out, v.Process(events.NewRunStartedEvent("t", "r"))...)
+		out = append(out, v.Process(events.NewToolCallArgsEvent("c1", "{}"))...)
+		out = append(out, v.Process(events.NewRunFinishedEvent("t", "r"))...)
+		out = append(out, v.Process(events.NewCustomEvent("late"))...)
+
+		assert.Equal(t, []events.EventType{events.EventTypeRunStarted, events.EventTypeRunFinished}, eventTypes(out))
+	})
+}
+
+func TestSequenceValidator_Log(t *testing.T) {
+	var logged []string
+	v := NewSequenceValidator(ValidationLog).WithLogger(loggerFunc(func(format string, args ...any) {
+		logged = append(logged, format)
+	}))
+
+	v.Process(events.NewRunStartedEvent("t", "r"))
+	evt := events.NewTextMessageEndEvent("m1")
+	out := v.Process(evt)
+
+	require.Len(t, out, 1)
+	assert.Same(t, evt, out[0])
+	assert.Len(t, logged, 1)
+	assert.Error(t, v.Err())
+}
+
+func TestSequenceValidator_Wrap(t *testing.T) {
+	in := make(chan events.Event, 4)
+	in <- events.NewRunStartedEvent("t", "r")
+	in <- events.NewToolCallEndEvent("c1")
+	in <- events.NewCustomEvent("dropped")
+	in <- events.NewRunFinishedEvent("t", "r")
+	close(in)
+
+	v := NewSequenceValidator(ValidationStrict)
+	var out []events.Event
+	for evt := range v.Wrap(context.Background(), in) {
+		out = append(out, evt)
+	}
+
+	assert.Equal(t, []events.EventType{events.EventTypeRunStarted, events.EventTypeRunError}, eventTypes(out))
+	assert.Error(t, v.Err())
+
+	t.Run("drains the input after the consumer stops", func(t *testing.T) {
+		in := make(chan events.Event)
+		ctx, cancel := context.WithCancel(context.Background())
+		out := NewSequenceValidator(ValidationStrict).Wrap(ctx, in)
+
+		in <- events.NewRunStartedEvent("t", "r")
+		<-out
+		cancel()
+
+		// The producer is not blocked although nobody reads the output
+		for i := 0; i < 10; i++ {
+			select {
+			case in <- events.NewCustomEvent("progress"):
+			case <-time.After(time.Second):
+				t.Fatal("producer blocked")
+			}
+		}
+		close(in)
+		for range out {
+		}
+	})
+}
+
+func TestSequenceValidator_NoErrorAfterFinish(t *testing.T) {
+	v := NewSequenceValidator(ValidationStrict)
+	out := v.Process(events.NewRunStartedEvent("t", "r"))
+	out = append(out, v.Process(events.NewRunFinishedEvent("t", "r"))...)
+	out = append(out, v.Process(events.NewCustomEvent("late"))...)
+	out = append(out, v.Close()...)
+
+	assert.Equal(t, []events.EventType{events.EventTypeRunStarted, events.EventTypeRunFinished}, eventTypes(out))
+	require.Error(t, v.Err())
+	assert.Contains(t, v.Err().Error(), "event after the run finished")
+	require.NoError(t, ValidateEvents(out))
+}
+
+func TestValidationInterceptor(t *testing.T) {
+	t.Run("repairs a generic handler stream", func(t *testing.T) {
+		source := &MockEventSource{
+			RunFunc: func(ctx HandlerContext, input RunAgentInput) <-chan events.Event {
+				ch := make(chan events.Event, 2)
+				ch <- events.NewTextMessageStartEvent("m1", events.WithRole("assistant"))
+				ch <- events.NewTextMessageContentEvent("m1", "forgot the lifecycle")
+				close(ch)
+				return ch
+			},
+		}
+		handler := New(Config{
+			EventSource:  source,
+			Interceptors: []EventInterceptor{NewValidationInterceptor(ValidationRepair, nil)},
+		})
+
+		body, _ := json.Marshal(RunAgentInput{ThreadID: "thread-1", RunID: "run-1"})
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		evts := decodeJSONEvents(t, rr.Body.Bytes())
+		require.Len(t, evts, 5)
+		assert.Equal(t, "RUN_STARTED", evts[0]["type"])
+		assert.Equal(t, "run-1", evts[0]["runId"])
+		assert.Equal(t, "TEXT_MESSAGE_END", evts[3]["type"])
+		assert.Equal(t, "RUN_FINISHED", evts[4]["type"])
+	})
+
+	t.Run("ADK handler stream is valid", func(t *testing.T) {
+		handler := newTestADKHandler(t,
+			[]model.LLMResponse{
+				{Content: genai.NewContentFromText("Hello", genai.RoleModel)},
+				{Content: genai.NewContentFromFunctionCall("lookup", map[string]any{"q": "x"}, genai.RoleModel)},
+			},
+			WithInterceptors(NewValidationInterceptor(ValidationStrict, nil)),
+		)
+
+		body := `{"threadId":"thread-1","messages":[{"id":"m1","role":"user","content":"Hi"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.NotContains(t, rr.Body.String(), SequenceErrorCode)
+		assert.Contains(t, rr.Body.String(), "RUN_FINISHED")
+	})
+}
+
+// loggerFunc adapts a function to the Logger interface
+type loggerFunc func(format string, v ...any)
+
+func (f loggerFunc) Printf(format string, v ...any) { f(format, v...) }
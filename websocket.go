@@ -0,0 +1,320 @@
+package aguigo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket frame types
+const (
+	// WSFrameRun starts a run; the frame carries a RunAgentInput
+	WSFrameRun = "run"
+	// WSFrameCancel cancels a run started on the same socket
+	WSFrameCancel = "cancel"
+	// WSFrameEvent carries one AG-UI event of a run
+	WSFrameEvent = "event"
+	// WSFrameError reports a frame that could not be handled
+	WSFrameError = "error"
+)
+
+// WebSocket connection defaults
+const (
+	defaultWSReadLimit    = 4 << 20
+	defaultWSPingInterval = 30 * time.Second
+	defaultWSWriteTimeout = 10 * time.Second
+)
+
+// errWebSocketClosed cancels the runs of a closed connection
+var errWebSocketClosed = errors.New("websocket closed")
+
+// wsClientFrame is the part of a client frame used for routing. Run frames
+// are a RunAgentInput with an optional "type" of "run".
+type wsClientFrame struct {
+	Type     string `json:"type"`
+	ThreadID string `json:"threadId"`
+	RunID    string `json:"runId"`
+}
+
+// wsServerFrame is a frame sent to the client. Event holds the same JSON
+// an SSE data line would.
+type wsServerFrame struct {
+	Type     string          `json:"type"`
+	ThreadID string          `json:"threadId,omitempty"`
+	RunID    string          `json:"runId,omitempty"`
+	Event    json.RawMessage `json:"event,omitempty"`
+	Message  string          `json:"message,omitempty"`
+}
+
+// WebSocketHandler serves AG-UI runs over a WebSocket. Each text frame from
+// the client is a RunAgentInput that starts a run, or a cancel frame naming
+// a run; several runs can stream on one socket at once. Every event is sent
+// as an "event" frame tagged with its thread and run ID.
+//
+// A run in flight can't take input: tool results are sent the AG-UI way,
+// as tool messages in the input of a follow-up run on the socket.
+type WebSocketHandler struct {
+	runs     *runScope
+	stream   func(HandlerContext, RunAgentInput, func([]events.Event) bool)
+	userID   func(*http.Request) string
+	upgrader websocket.Upgrader
+
+	readLimit    int64
+	pingInterval time.Duration
+	writeTimeout time.Duration
+}
+
+func newWebSocketHandler(runs *runScope, userID func(*http.Request) string, stream func(HandlerContext, RunAgentInput, func([]events.Event) bool)) *WebSocketHandler {
+	return &WebSocketHandler{
+		runs:         runs,
+		stream:       stream,
+		userID:       userID,
+		readLimit:    defaultWSReadLimit,
+		pingInterval: defaultWSPingInterval,
+		writeTimeout: defaultWSWriteTimeout,
+	}
+}
+
+// WithCheckOrigin sets the function that accepts or rejects the Origin of
+// an upgrade request. By default only same-origin requests are accepted.
+func (h *WebSocketHandler) WithCheckOrigin(check func(r *http.Request) bool) *WebSocketHandler {
+	h.upgrader.CheckOrigin = check
+	return h
+}
+
+// WithReadLimit sets the largest frame a client may send, in bytes. A larger
+// frame closes the connection. Defaults to 4 MiB.
+func (h *WebSocketHandler) WithReadLimit(limit int64) *WebSocketHandler {
+	h.readLimit = limit
+	return h
+}
+
+// WithPingInterval sets how often the server pings the client. A client
+// that sends nothing, not even a pong, for two intervals is disconnected.
+// Defaults to 30 seconds; zero turns pings and the read timeout off.
+func (h *WebSocketHandler) WithPingInterval(interval time.Duration) *WebSocketHandler {
+	h.pingInterval = interval
+	return h
+}
+
+// WithWriteTimeout sets how long a frame may take to write before the
+// connection is dropped. Defaults to 10 seconds.
+func (h *WebSocketHandler) WithWriteTimeout(timeout time.Duration) *WebSocketHandler {
+	h.writeTimeout = timeout
+	return h
+}
+
+// ServeHTTP upgrades the connection and serves runs until the client goes
+// away, which cancels the runs still in flight
+func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already replied with an HTTP error
+		return
+	}
+
+	s := &wsSession{
+		handler: h,
+		conn:    conn,
+		request: r,
+		active:  make(map[runKey]context.CancelCauseFunc),
+	}
+	s.serve()
+}
+
+// wsSession is one WebSocket connection and the runs started on it
+type wsSession struct {
+	handler *WebSocketHandler
+	conn    *websocket.Conn
+	request *http.Request
+
+	writeMu sync.Mutex
+
+	mu     sync.Mutex
+	active map[runKey]context.CancelCauseFunc
+	wg     sync.WaitGroup
+}
+
+// serve reads client frames until the connection closes
+func (s *wsSession) serve() {
+	ctx, cancel := context.WithCancelCause(s.request.Context())
+	defer func() {
+		cancel(errWebSocketClosed)
+		s.conn.Close()
+		s.wg.Wait()
+	}()
+
+	if s.handler.readLimit > 0 {
+		s.conn.SetReadLimit(s.handler.readLimit)
+	}
+	if s.handler.pingInterval > 0 {
+		s.extendReadDeadline()
+		s.conn.SetPongHandler(func(string) error {
+			s.extendReadDeadline()
+			return nil
+		})
+		s.wg.Add(1)
+		go s.ping(ctx)
+	}
+
+	for {
+		msgType, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if s.handler.pingInterval > 0 {
+			s.extendReadDeadline()
+		}
+		if msgType != websocket.TextMessage {
+			s.sendError("", "", "frames must be JSON text messages")
+			continue
+		}
+
+		var frame wsClientFrame
+		if err := json.Unmarshal(data, &frame); err != nil {
+			s.sendError("", "", fmt.Sprintf("Invalid JSON: %v", err))
+			continue
+		}
+
+		switch frame.Type {
+		case "", WSFrameRun:
+			var input RunAgentInput
+			if err := json.Unmarshal(data, &input); err != nil {
+				s.sendError(frame.ThreadID, frame.RunID, fmt.Sprintf("Invalid JSON: %v", err))
+				continue
+			}
+			s.startRun(ctx, input)
+		case WSFrameCancel:
+			if !s.cancelRun(frame.ThreadID, frame.RunID) {
+				s.sendError(frame.ThreadID, frame.RunID, ErrRunNotFound.Error())
+			}
+		default:
+			s.sendError(frame.ThreadID, frame.RunID, fmt.Sprintf("unknown frame type %q", frame.Type))
+		}
+	}
+}
+
+// ping pings the client until the connection closes. A failed ping closes
+// the connection, which ends serve.
+func (s *wsSession) ping(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.handler.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, s.writeDeadline()); err != nil {
+				s.conn.Close()
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// extendReadDeadline gives the client two ping intervals to show it is alive
+func (s *wsSession) extendReadDeadline() {
+	s.conn.SetReadDeadline(time.Now().Add(2 * s.handler.pingInterval))
+}
+
+// writeDeadline returns the deadline of a write starting now, or no
+// deadline without a write timeout
+func (s *wsSession) writeDeadline() time.Time {
+	if s.handler.writeTimeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(s.handler.writeTimeout)
+}
+
+// startRun streams a run in the background
+func (s *wsSession) startRun(ctx context.Context, input RunAgentInput) {
+	if input.ThreadID == "" {
+		input.ThreadID = events.GenerateThreadID()
+	}
+	if input.RunID == "" {
+		input.RunID = events.GenerateRunID()
+	}
+
+	key := runKey{input.ThreadID, input.RunID}
+	runCtx, cancelRun := context.WithCancelCause(ctx)
+
+	s.mu.Lock()
+	if _, ok := s.active[key]; ok {
+		s.mu.Unlock()
+		cancelRun(nil)
+		s.sendError(input.ThreadID, input.RunID, "Run already exists")
+		return
+	}
+	s.active[key] = cancelRun
+	s.mu.Unlock()
+
+	hctx := HandlerContext{
+		ThreadID: input.ThreadID,
+		RunID:    input.RunID,
+		UserID:   s.handler.userID(s.request),
+		Request:  s.request,
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.active, key)
+			s.mu.Unlock()
+			cancelRun(nil)
+		}()
+
+		// Like a streaming request, the run also stops on shutdown, timeout
+		// or through the run registry
+		runCtx, cancel := s.handler.runs.runContext(runCtx, hctx)
+		defer cancel()
+		hctx.Context = runCtx
+
+		s.handler.stream(hctx, input, func(evts []events.Event) bool {
+			for _, evt := range evts {
+				data, err := evt.ToJSON()
+				if err != nil {
+					continue
+				}
+				if err := s.send(wsServerFrame{Type: WSFrameEvent, ThreadID: input.ThreadID, RunID: input.RunID, Event: data}); err != nil {
+					return false
+				}
+			}
+			return true
+		})
+	}()
+}
+
+// cancelRun cancels a run started on this socket
+func (s *wsSession) cancelRun(threadID, runID string) bool {
+	s.mu.Lock()
+	cancel, ok := s.active[runKey{threadID, runID}]
+	s.mu.Unlock()
+
+	if ok {
+		cancel(ErrRunCancelled)
+	}
+	return ok
+}
+
+// send writes one frame; runs share the connection, so writes are serialized
+func (s *wsSession) send(frame wsServerFrame) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.SetWriteDeadline(s.writeDeadline())
+	return s.conn.WriteJSON(frame)
+}
+
+func (s *wsSession) sendError(threadID, runID, message string) {
+	s.send(wsServerFrame{Type: WSFrameError, ThreadID: threadID, RunID: runID, Message: message})
+}
@@ -0,0 +1,219 @@
+package aguigo
+
+import (
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// dialWebSocket starts a test server for h and connects to it
+func dialWebSocket(t *testing.T, h http.Handler) *websocket.Conn {
+	t.Helper()
+
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readFrames reads server frames until stop returns true
+func readFrames(t *testing.T, conn *websocket.Conn, stop func(wsServerFrame) bool) []wsServerFrame {
+	t.Helper()
+
+	var frames []wsServerFrame
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var frame wsServerFrame
+		require.NoError(t, conn.ReadJSON(&frame))
+		frames = append(frames, frame)
+		if stop(frame) {
+			return frames
+		}
+	}
+}
+
+// frameEventType returns the AG-UI event type carried by an event frame
+func frameEventType(t *testing.T, frame wsServerFrame) string {
+	t.Helper()
+	return jsonEventType(t, frame.Event)
+}
+
+// runEnded reports whether frame carries a run's terminal event
+func runEnded(t *testing.T) func(wsServerFrame) bool {
+	return func(frame wsServerFrame) bool {
+		if frame.Type != WSFrameEvent {
+			return false
+		}
+		typ := frameEventType(t, frame)
+		return typ == "RUN_FINISHED" || typ == "RUN_ERROR"
+	}
+}
+
+func TestWebSocketHandler_MultiplexesRuns(t *testing.T) {
+	gate := make(chan struct{})
+	handler := New(Config{
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return func(yield func(events.Event, error) bool) {
+				if input.RunID == "slow" {
+					select {
+					case <-gate:
+					case <-ctx.Done():
+						return
+					}
+				}
+				yield(events.NewCustomEvent(input.RunID), nil)
+			}
+		}),
+	})
+	conn := dialWebSocket(t, handler.WebSocket())
+
+	require.NoError(t, conn.WriteJSON(RunAgentInput{ThreadID: "thread-1", RunID: "slow"}))
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "run", "threadId": "thread-1", "runId": "fast"}))
+
+	// The fast run finishes while the slow one is still going
+	frames := readFrames(t, conn, runEnded(t))
+	last := frames[len(frames)-1]
+	assert.Equal(t, "fast", last.RunID)
+	assert.Equal(t, "RUN_FINISHED", frameEventType(t, last))
+
+	close(gate)
+	frames = readFrames(t, conn, runEnded(t))
+	last = frames[len(frames)-1]
+	assert.Equal(t, "slow", last.RunID)
+	assert.Equal(t, "thread-1", last.ThreadID)
+	assert.Equal(t, "RUN_FINISHED", frameEventType(t, last))
+}
+
+func TestWebSocketHandler_Cancel(t *testing.T) {
+	handler := New(Config{
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return func(yield func(events.Event, error) bool) {
+				if !yield(events.NewCustomEvent("working"), nil) {
+					return
+				}
+				<-ctx.Done()
+			}
+		}),
+	})
+	conn := dialWebSocket(t, handler.WebSocket())
+
+	require.NoError(t, conn.WriteJSON(RunAgentInput{ThreadID: "thread-1", RunID: "run-1"}))
+	readFrames(t, conn, func(f wsServerFrame) bool { return frameEventType(t, f) == "CUSTOM" })
+
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "cancel", "threadId": "thread-1", "runId": "run-1"}))
+	frames := readFrames(t, conn, runEnded(t))
+
+	var evt map[string]any
+	require.NoError(t, json.Unmarshal(frames[len(frames)-1].Event, &evt))
+	assert.Equal(t, "RUN_ERROR", evt["type"])
+	assert.Equal(t, ErrRunCancelled.Error(), evt["message"])
+
+	t.Run("unknown run", func(t *testing.T) {
+		require.NoError(t, conn.WriteJSON(map[string]any{"type": "cancel", "threadId": "thread-1", "runId": "missing"}))
+		frames := readFrames(t, conn, func(wsServerFrame) bool { return true })
+		assert.Equal(t, wsServerFrame{Type: WSFrameError, ThreadID: "thread-1", RunID: "missing", Message: ErrRunNotFound.Error()}, frames[0])
+	})
+
+	t.Run("invalid frames", func(t *testing.T) {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{nope")))
+		frames := readFrames(t, conn, func(wsServerFrame) bool { return true })
+		assert.Equal(t, WSFrameError, frames[0].Type)
+
+		require.NoError(t, conn.WriteJSON(map[string]any{"type": "subscribe"}))
+		frames = readFrames(t, conn, func(wsServerFrame) bool { return true })
+		assert.Contains(t, frames[0].Message, "unknown frame type")
+	})
+}
+
+func TestWebSocketHandler_Connection(t *testing.T) {
+	handler := New(Config{
+		EventSource: SeqFunc(func(ctx HandlerContext, input RunAgentInput) iter.Seq2[events.Event, error] {
+			return func(yield func(events.Event, error) bool) {}
+		}),
+	})
+
+	t.Run("read limit", func(t *testing.T) {
+		conn := dialWebSocket(t, handler.WebSocket().WithReadLimit(64))
+
+		require.NoError(t, conn.WriteJSON(RunAgentInput{ThreadID: strings.Repeat("t", 100)}))
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, _, err := conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig), err)
+	})
+
+	t.Run("pings keep the client alive", func(t *testing.T) {
+		conn := dialWebSocket(t, handler.WebSocket().WithPingInterval(20*time.Millisecond))
+		pings := make(chan struct{}, 10)
+		conn.SetPingHandler(func(data string) error {
+			select {
+			case pings <- struct{}{}:
+			default:
+			}
+			return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+		go func() {
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		// Well past the two intervals a silent client gets
+		for i := 0; i < 5; i++ {
+			select {
+			case <-pings:
+			case <-time.After(2 * time.Second):
+				t.Fatal("no ping")
+			}
+		}
+	})
+
+	t.Run("silent client is dropped", func(t *testing.T) {
+		conn := dialWebSocket(t, handler.WebSocket().WithPingInterval(20*time.Millisecond))
+		conn.SetPingHandler(func(string) error { return nil })
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				var netErr interface{ Timeout() bool }
+				assert.False(t, errors.As(err, &netErr) && netErr.Timeout(), "the server kept the connection: %v", err)
+				return
+			}
+		}
+	})
+}
+
+func TestADKHandler_WebSocket(t *testing.T) {
+	handler := newTestADKHandler(t, []model.LLMResponse{
+		{Content: genai.NewContentFromText("Hello over WebSocket", genai.RoleModel)},
+	})
+	conn := dialWebSocket(t, handler.WebSocket())
+
+	input := `{"threadId":"thread-1","runId":"run-1","messages":[{"id":"m1","role":"user","content":"Hi"}]}`
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(input)))
+
+	var types []string
+	for _, frame := range readFrames(t, conn, runEnded(t)) {
+		require.Equal(t, WSFrameEvent, frame.Type)
+		types = append(types, frameEventType(t, frame))
+	}
+	assert.Equal(t, "RUN_STARTED", types[0])
+	assert.Contains(t, types, "TEXT_MESSAGE_CONTENT")
+	assert.Equal(t, "RUN_FINISHED", types[len(types)-1])
+}